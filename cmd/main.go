@@ -23,6 +23,14 @@ func main() {
 	}
 	defer logger.Sync() // Flushes buffer, if any
 
+	// Run database migrations instead of the server when invoked as `migrate ...`
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], logger); err != nil {
+			logger.Fatal("Migration failed", zap.Error(err))
+		}
+		return
+	}
+
 	// Initialize config
 	cfg, err := config.Init()
 	if err != nil {
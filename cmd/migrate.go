@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"chat-backend-general/config"
+	"chat-backend-general/internal/infra/database"
+
+	"go.uber.org/zap"
+)
+
+const migrateUsage = "usage: migrate up [n] | down [n] | status"
+
+// runMigrate implements the `migrate` subcommand against DATABASE_URL.
+func runMigrate(args []string, logger *zap.Logger) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid step count %q, %s", args[1], migrateUsage)
+		}
+		steps = n
+	}
+
+	cfg, err := config.InitDatabase()
+	if err != nil {
+		return err
+	}
+	db, err := database.NewPostgresDB(*cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, steps)
+		for _, migration := range applied {
+			logger.Info("Applied migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		}
+		if err == nil && len(applied) == 0 {
+			logger.Info("No pending migrations")
+		}
+		return err
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			logger.Info("Rolled back migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		}
+		return err
+	case "status":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
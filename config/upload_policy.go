@@ -0,0 +1,167 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DefaultAllowedTypes are the document types accepted when UPLOAD_ALLOWED_TYPES is not set.
+var DefaultAllowedTypes = []string{
+	"application/msword", "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.ms-powerpoint", "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"application/vnd.ms-excel", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.oasis.opendocument.text", "application/vnd.oasis.opendocument.presentation", "application/vnd.oasis.opendocument.spreadsheet",
+	"text/plain", "application/pdf",
+	"text/csv", "text/html",
+	"application/xliff+xml",
+	"text/markdown",
+	"application/vnd.ms-outlook",
+	"text/rtf",
+	"text/tab-separated-values",
+}
+
+// DefaultAllowedExtensions are the file extensions accepted when UPLOAD_ALLOWED_EXTENSIONS is not set.
+var DefaultAllowedExtensions = []string{
+	".doc", ".docx", ".ppt", ".pptx", ".xls", ".xlsx", ".odt", ".odp", ".ods",
+	".txt", ".pdf", ".csv", ".htm", ".html", ".xlf", ".xliff", ".md", ".markdown",
+	".msg", ".rtf", ".tsv",
+}
+
+// UploadPolicy limits what clients can upload and how much they can store.
+type UploadPolicy struct {
+	MaxSize           int64            `json:"maxSize"`
+	AllowedTypes      []string         `json:"allowedTypes"`
+	AllowedExtensions []string         `json:"allowedExtensions"`
+	TypeLimits        map[string]int64 `json:"typeLimits"`
+	Quota             QuotaLimits      `json:"quota"`
+	Archive           ArchiveLimits    `json:"archive"`
+	// TenantQuotas replaces Quota for the listed usernames.
+	TenantQuotas map[string]QuotaLimits `json:"tenantQuotas"`
+}
+
+// LoadUploadPolicy builds the upload policy from the UPLOAD_* settings, applies the
+// policy file on top when one is configured, and validates the result.
+func LoadUploadPolicy(cfg UploadConfig) (UploadPolicy, error) {
+	policy := UploadPolicy{
+		MaxSize:           cfg.MaxSize,
+		AllowedTypes:      cfg.AllowedTypes,
+		AllowedExtensions: cfg.AllowedExtensions,
+		TypeLimits:        cfg.TypeLimits,
+		Quota:             cfg.Quota,
+		Archive:           cfg.Archive,
+	}
+	if len(policy.AllowedTypes) == 0 {
+		policy.AllowedTypes = DefaultAllowedTypes
+	}
+	if len(policy.AllowedExtensions) == 0 {
+		policy.AllowedExtensions = DefaultAllowedExtensions
+	}
+
+	if cfg.PolicyFile != "" {
+		data, err := os.ReadFile(cfg.PolicyFile)
+		if err != nil {
+			return UploadPolicy{}, fmt.Errorf("failed to read upload policy file: %w", err)
+		}
+		// Keys missing from the file keep the values from the environment.
+		if err := json.Unmarshal(data, &policy); err != nil {
+			return UploadPolicy{}, fmt.Errorf("failed to parse upload policy file %s: %w", cfg.PolicyFile, err)
+		}
+	}
+
+	policy = policy.normalize()
+	if err := policy.Validate(); err != nil {
+		return UploadPolicy{}, fmt.Errorf("invalid upload policy: %w", err)
+	}
+	return policy, nil
+}
+
+// Validate checks that the policy is usable: positive size limits, well-formed types and
+// extensions, per-type limits only for allowed types, and non-negative quotas and archive limits.
+func (p UploadPolicy) Validate() error {
+	var errs []error
+	if p.MaxSize <= 0 {
+		errs = append(errs, fmt.Errorf("maxSize must be positive, got %d", p.MaxSize))
+	}
+	if len(p.AllowedTypes) == 0 {
+		errs = append(errs, errors.New("allowedTypes must not be empty"))
+	}
+	if len(p.AllowedExtensions) == 0 {
+		errs = append(errs, errors.New("allowedExtensions must not be empty"))
+	}
+	for _, contentType := range p.AllowedTypes {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		main, sub, _ := strings.Cut(mediaType, "/")
+		if err != nil || mediaType != contentType || len(params) > 0 || main == "" || sub == "" {
+			errs = append(errs, fmt.Errorf("allowed type %q is not a media type", contentType))
+		}
+	}
+	for _, ext := range p.AllowedExtensions {
+		if len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext[1:], `./\ `) {
+			errs = append(errs, fmt.Errorf("allowed extension %q must look like .pdf", ext))
+		}
+	}
+	for contentType, limit := range p.TypeLimits {
+		if limit <= 0 {
+			errs = append(errs, fmt.Errorf("size limit of %q must be positive, got %d", contentType, limit))
+		}
+		if !slices.Contains(p.AllowedTypes, contentType) {
+			errs = append(errs, fmt.Errorf("size limit given for %q, which is not an allowed type", contentType))
+		}
+	}
+	errs = append(errs, p.Quota.validate("quota"))
+	if p.Archive.MaxEntries < 0 || p.Archive.MaxUncompressedSize < 0 || p.Archive.MaxRatio < 0 {
+		errs = append(errs, fmt.Errorf("archive limits must not be negative, got %+v", p.Archive))
+	}
+	for username, quota := range p.TenantQuotas {
+		if strings.TrimSpace(username) == "" || strings.ContainsAny(username, `/\`) {
+			errs = append(errs, fmt.Errorf("tenant quota given for invalid username %q", username))
+		}
+		errs = append(errs, quota.validate(fmt.Sprintf("quota of %q", username)))
+	}
+	return errors.Join(errs...)
+}
+
+func (q QuotaLimits) validate(name string) error {
+	if q.MaxBytes < 0 || q.MaxFiles < 0 || q.ChatMaxBytes < 0 || q.ChatMaxFiles < 0 {
+		return fmt.Errorf("%s must not be negative, got %+v", name, q)
+	}
+	return nil
+}
+
+// normalize lower-cases and trims types and extensions and drops duplicates.
+func (p UploadPolicy) normalize() UploadPolicy {
+	normalized := UploadPolicy{
+		MaxSize:           p.MaxSize,
+		AllowedTypes:      dedupe(p.AllowedTypes),
+		AllowedExtensions: dedupe(p.AllowedExtensions),
+		Quota:             p.Quota,
+		Archive:           p.Archive,
+		TenantQuotas:      p.TenantQuotas,
+	}
+	if len(p.TypeLimits) > 0 {
+		normalized.TypeLimits = make(map[string]int64, len(p.TypeLimits))
+		for contentType, limit := range p.TypeLimits {
+			normalized.TypeLimits[strings.ToLower(strings.TrimSpace(contentType))] = limit
+		}
+	}
+	return normalized
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
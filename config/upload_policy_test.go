@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadUploadPolicy_Defaults(t *testing.T) {
+	policy, err := LoadUploadPolicy(UploadConfig{MaxSize: 1024})
+	if err != nil {
+		t.Fatalf("LoadUploadPolicy() error = %v", err)
+	}
+	if len(policy.AllowedTypes) != len(DefaultAllowedTypes) || len(policy.AllowedExtensions) != len(DefaultAllowedExtensions) {
+		t.Errorf("LoadUploadPolicy() = %+v, want the default types and extensions", policy)
+	}
+}
+
+func TestLoadUploadPolicy_NormalizesEnvironment(t *testing.T) {
+	policy, err := LoadUploadPolicy(UploadConfig{
+		MaxSize:           1024,
+		AllowedTypes:      []string{"application/pdf", " Text/Plain", "application/pdf"},
+		AllowedExtensions: []string{".PDF", ".txt", ".txt"},
+		TypeLimits:        map[string]int64{"APPLICATION/PDF": 4096},
+	})
+	if err != nil {
+		t.Fatalf("LoadUploadPolicy() error = %v", err)
+	}
+	if !slices.Equal(policy.AllowedTypes, []string{"application/pdf", "text/plain"}) {
+		t.Errorf("LoadUploadPolicy().AllowedTypes = %v, want [application/pdf text/plain]", policy.AllowedTypes)
+	}
+	if !slices.Equal(policy.AllowedExtensions, []string{".pdf", ".txt"}) {
+		t.Errorf("LoadUploadPolicy().AllowedExtensions = %v, want [.pdf .txt]", policy.AllowedExtensions)
+	}
+	if policy.TypeLimits["application/pdf"] != 4096 {
+		t.Errorf("LoadUploadPolicy().TypeLimits = %v, want application/pdf:4096", policy.TypeLimits)
+	}
+}
+
+func TestLoadUploadPolicy_PolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	content := `{"maxSize": 2048, "allowedTypes": ["application/pdf"], "typeLimits": {"application/pdf": 8192}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadUploadPolicy(UploadConfig{MaxSize: 1024, AllowedExtensions: []string{".pdf"}, PolicyFile: path})
+	if err != nil {
+		t.Fatalf("LoadUploadPolicy() error = %v", err)
+	}
+	if policy.MaxSize != 2048 || !slices.Equal(policy.AllowedTypes, []string{"application/pdf"}) ||
+		!slices.Equal(policy.AllowedExtensions, []string{".pdf"}) || policy.TypeLimits["application/pdf"] != 8192 {
+		t.Errorf("LoadUploadPolicy() = %+v, want file values with extensions from the environment", policy)
+	}
+}
+
+func TestLoadUploadPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  UploadConfig
+	}{
+		{name: "zero max size", cfg: UploadConfig{MaxSize: 0}},
+		{name: "malformed type", cfg: UploadConfig{MaxSize: 1024, AllowedTypes: []string{"pdf"}}},
+		{name: "type with parameters", cfg: UploadConfig{MaxSize: 1024, AllowedTypes: []string{"text/plain; charset=utf-8"}}},
+		{name: "extension without dot", cfg: UploadConfig{MaxSize: 1024, AllowedExtensions: []string{"pdf"}}},
+		{name: "limit for type not allowed", cfg: UploadConfig{MaxSize: 1024, AllowedTypes: []string{"text/plain"}, TypeLimits: map[string]int64{"application/pdf": 10}}},
+		{name: "negative type limit", cfg: UploadConfig{MaxSize: 1024, AllowedTypes: []string{"text/plain"}, TypeLimits: map[string]int64{"text/plain": -1}}},
+		{name: "negative archive ratio", cfg: UploadConfig{MaxSize: 1024, Archive: ArchiveLimits{MaxRatio: -1}}},
+		{name: "missing policy file", cfg: UploadConfig{MaxSize: 1024, PolicyFile: filepath.Join(t.TempDir(), "missing.json")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadUploadPolicy(tt.cfg); err == nil {
+				t.Errorf("LoadUploadPolicy(%+v) error = nil, want an error", tt.cfg)
+			}
+		})
+	}
+}
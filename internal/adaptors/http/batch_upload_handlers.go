@@ -0,0 +1,118 @@
+package http
+
+import (
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchUploadHandler uploads several files sent in one multipart request.
+type BatchUploadHandler struct {
+	batchUploadUseCase usecases.BatchUploadUseCase
+	maxFiles           int
+}
+
+// NewBatchUploadHandler creates a BatchUploadHandler accepting up to maxFiles files per request.
+func NewBatchUploadHandler(batchUploadUseCase usecases.BatchUploadUseCase, maxFiles int) *BatchUploadHandler {
+	return &BatchUploadHandler{batchUploadUseCase: batchUploadUseCase, maxFiles: maxFiles}
+}
+
+// batchUploadItem is the outcome of one file of a batch upload. Status is the HTTP status
+// the file would have got from POST /doc/upload.
+type batchUploadItem struct {
+	Filename string                  `json:"filename"`
+	Status   int                     `json:"status"`
+	FileID   string                  `json:"fileId,omitempty"`
+	Path     string                  `json:"path,omitempty"`
+	Checksum string                  `json:"checksum,omitempty"`
+	Version  int                     `json:"version,omitempty"`
+	TaskID   string                  `json:"taskId,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+	Code     string                  `json:"code,omitempty"`
+	Details  domain.ValidationErrors `json:"details,omitempty"`
+}
+
+// UploadFiles uploads every file sent as a "files" part, along with username and chatid.
+// It responds 200 when every file was uploaded and 207 Multi-Status when some were not,
+// listing the outcome of each file in the order they were sent.
+func (b *BatchUploadHandler) UploadFiles(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["files"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files provided"})
+		return
+	}
+	headers := form.File["files"]
+	if len(headers) > b.maxFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d files can be uploaded at once", b.maxFiles)})
+		return
+	}
+	uname, chatid := c.PostForm("username"), c.PostForm("chatid")
+	checksums := form.Value["checksum"]
+	if len(checksums) > 0 && len(checksums) != len(headers) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send one checksum per file, in the order of the files"})
+		return
+	}
+
+	files := make([]domain.UploadedFile, 0, len(headers))
+	for i, header := range headers {
+		// The digest of each file is sent as the checksum form field at its position, or as
+		// the Content-Digest header of its form part; the request's covers no single file.
+		var formChecksum string
+		if len(checksums) > 0 {
+			formChecksum = checksums[i]
+		}
+		checksum, err := uploadChecksum(formChecksum, header.Header.Get("Content-Digest"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid checksum of %q", header.Filename), "details": err.Error()})
+			return
+		}
+		fileData, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer fileData.Close()
+
+		files = append(files, domain.UploadedFile{
+			Name:        header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			File:        fileData,
+			Size:        header.Size,
+			Owner:       uname,
+			ChatID:      chatid,
+			Checksum:    checksum,
+		})
+	}
+
+	results := b.batchUploadUseCase.HandleBatchUpload(c.Request.Context(), files)
+
+	status := http.StatusOK
+	items := make([]batchUploadItem, len(results))
+	for i, result := range results {
+		items[i] = newBatchUploadItem(result)
+		if result.Err != nil {
+			status = http.StatusMultiStatus
+		}
+	}
+	c.JSON(status, gin.H{"files": items})
+}
+
+func newBatchUploadItem(result domain.BatchUploadResult) batchUploadItem {
+	item := batchUploadItem{Filename: result.Name}
+	var failures domain.ValidationErrors
+	switch {
+	case result.Err == nil:
+		item.Status = http.StatusOK
+		item.FileID, item.Path, item.Checksum, item.Version = result.Result.FileID, result.Result.Path, result.Result.Checksum, result.Result.Version
+		item.TaskID = result.Result.TaskID
+	case errors.As(result.Err, &failures):
+		item.Status, item.Error, item.Code, item.Details = http.StatusBadRequest, "File validation failed", validationCode, failures
+	default:
+		item.Status, item.Error = fileErrorStatus(result.Err), fileErrorMessage(result.Err, "Failed to upload file")
+	}
+	return item
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chat-backend-general/internal/adaptors/repository"
+	"chat-backend-general/internal/adaptors/validation"
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+)
+
+func TestBatchUploadHandler_UploadFiles(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	upload := usecases.NewFileUploadUseCase(repo, metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""))
+	validators := []domain.FileValidator{validation.NewFileExtensionValidator([]string{".txt"})}
+	r.POST("/doc/upload/batch", NewBatchUploadHandler(usecases.NewBatchUploadUseCase(upload, validators, 2), 3).UploadFiles)
+
+	checksum := func(filename string) string {
+		sum := sha256.Sum256([]byte("content of " + filename))
+		return hex.EncodeToString(sum[:])
+	}
+
+	tests := []struct {
+		name      string
+		filenames []string
+		checksums []string
+		status    int
+		statuses  []int
+	}{
+		{name: "all uploaded", filenames: []string{"a.txt", "b.txt"}, status: http.StatusOK, statuses: []int{http.StatusOK, http.StatusOK}},
+		{name: "partially uploaded", filenames: []string{"a.txt", "setup.exe", "c.txt"}, status: http.StatusMultiStatus,
+			statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusOK}},
+		{name: "too many files", filenames: []string{"a.txt", "b.txt", "c.txt", "d.txt"}, status: http.StatusBadRequest},
+		{name: "no files", status: http.StatusBadRequest},
+		{name: "checksums", filenames: []string{"a.txt", "b.txt"}, checksums: []string{checksum("a.txt"), checksum("a.txt")}, status: http.StatusMultiStatus,
+			statuses: []int{http.StatusOK, http.StatusBadRequest}},
+		{name: "checksum missing", filenames: []string{"a.txt", "b.txt"}, checksums: []string{checksum("a.txt")}, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			form.WriteField("username", "alice")
+			form.WriteField("chatid", "chat-1")
+			for _, checksum := range tt.checksums {
+				form.WriteField("checksum", checksum)
+			}
+			for _, filename := range tt.filenames {
+				part, _ := form.CreateFormFile("files", filename)
+				part.Write([]byte("content of " + filename))
+			}
+			form.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/doc/upload/batch", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %q)", w.Code, tt.status, w.Body.String())
+			}
+			var response struct {
+				Files []batchUploadItem `json:"files"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("batch upload response: %v", err)
+			}
+			if len(response.Files) != len(tt.statuses) {
+				t.Fatalf("files = %+v, want %d results", response.Files, len(tt.statuses))
+			}
+			for i, item := range response.Files {
+				if item.Filename != tt.filenames[i] || item.Status != tt.statuses[i] || (item.Status == http.StatusOK) != (item.FileID != "") {
+					t.Errorf("file %d = %+v, want %s with status %d", i, item, tt.filenames[i], tt.statuses[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// internal/adaptors/http/byte_range.go
+package http
+
+import (
+	"chat-backend-general/internal/domain"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// errRangeNotSatisfiable is returned when none of the requested bytes exist in the file.
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// parseByteRange resolves a Range header against a file of the given size.
+// It returns nil when the whole file should be served: no header, a header it
+// does not understand, or a multi-range request, all of which RFC 9110 allows
+// a server to ignore.
+func parseByteRange(header string, size int64) (*domain.ByteRange, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	// Suffix range: the last N bytes of the file.
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+		if n == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return &domain.ByteRange{Offset: size - n, Length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return &domain.ByteRange{Offset: start, Length: end - start + 1}, nil
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		size     int64
+		expected *domain.ByteRange
+		err      error
+	}{
+		{name: "no header", header: "", size: 100, expected: nil},
+		{name: "bounded range", header: "bytes=0-9", size: 100, expected: &domain.ByteRange{Offset: 0, Length: 10}},
+		{name: "open ended range", header: "bytes=90-", size: 100, expected: &domain.ByteRange{Offset: 90, Length: 10}},
+		{name: "end clamped to size", header: "bytes=50-500", size: 100, expected: &domain.ByteRange{Offset: 50, Length: 50}},
+		{name: "suffix range", header: "bytes=-20", size: 100, expected: &domain.ByteRange{Offset: 80, Length: 20}},
+		{name: "suffix larger than file", header: "bytes=-500", size: 100, expected: &domain.ByteRange{Offset: 0, Length: 100}},
+		{name: "start past end", header: "bytes=100-", size: 100, err: errRangeNotSatisfiable},
+		{name: "empty file", header: "bytes=0-", size: 0, err: errRangeNotSatisfiable},
+		{name: "zero suffix", header: "bytes=-0", size: 100, err: errRangeNotSatisfiable},
+		{name: "multiple ranges ignored", header: "bytes=0-1,5-6", size: 100, expected: nil},
+		{name: "other unit ignored", header: "items=0-1", size: 100, expected: nil},
+		{name: "reversed range ignored", header: "bytes=9-0", size: 100, expected: nil},
+		{name: "garbage ignored", header: "bytes=a-b", size: 100, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseByteRange(tt.header, tt.size)
+			if err != tt.err {
+				t.Fatalf("parseByteRange(%q, %d) error = %v, want %v", tt.header, tt.size, err, tt.err)
+			}
+			if (got == nil) != (tt.expected == nil) || (got != nil && *got != *tt.expected) {
+				t.Errorf("parseByteRange(%q, %d) = %v, want %v", tt.header, tt.size, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// internal/adaptors/http/content_digest.go
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// errDigestInvalid is returned for Content-Digest headers without a usable sha-256 digest.
+var errDigestInvalid = errors.New("Content-Digest must carry a sha-256 digest, e.g. sha-256=:<base64>:")
+
+// parseContentDigest returns the hex-encoded SHA-256 of an RFC 9530 Content-Digest
+// header, or "" when there is no header. Digests of other algorithms are ignored, but a
+// header with no sha-256 digest is rejected rather than silently going unverified.
+func parseContentDigest(header string) (string, error) {
+	if strings.TrimSpace(header) == "" {
+		return "", nil
+	}
+
+	for _, member := range strings.Split(header, ",") {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || !strings.EqualFold(algorithm, "sha-256") {
+			continue
+		}
+		encoded, ok := strings.CutPrefix(value, ":")
+		if encoded, ok = strings.CutSuffix(encoded, ":"); !ok {
+			return "", errDigestInvalid
+		}
+		digest, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(digest) != sha256.Size {
+			return "", errDigestInvalid
+		}
+		return hex.EncodeToString(digest), nil
+	}
+	return "", errDigestInvalid
+}
+
+// errChecksumInvalid is returned for checksum form fields that are not a hex-encoded SHA-256.
+var errChecksumInvalid = errors.New("checksum must be a hex-encoded SHA-256")
+
+// errChecksumConflict is returned when a file is sent with digests that differ.
+var errChecksumConflict = errors.New("the digests sent for the file differ")
+
+// uploadChecksum returns the hex-encoded SHA-256 a client sent for an uploaded file, as a
+// checksum form field, in hex, or in Content-Digest headers, or "" when it sent none. The
+// digests that are given must agree.
+func uploadChecksum(formChecksum string, digestHeaders ...string) (string, error) {
+	checksum := strings.ToLower(strings.TrimSpace(formChecksum))
+	if checksum != "" {
+		if sum, err := hex.DecodeString(checksum); err != nil || len(sum) != sha256.Size {
+			return "", errChecksumInvalid
+		}
+	}
+	for _, header := range digestHeaders {
+		digest, err := parseContentDigest(header)
+		if err != nil {
+			return "", err
+		}
+		if digest == "" {
+			continue
+		}
+		if checksum != "" && checksum != digest {
+			return "", errChecksumConflict
+		}
+		checksum = digest
+	}
+	return checksum, nil
+}
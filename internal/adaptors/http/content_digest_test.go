@@ -0,0 +1,37 @@
+package http
+
+import "testing"
+
+func TestParseContentDigest(t *testing.T) {
+	// SHA-256 of "hello".
+	const hexDigest = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	const b64Digest = "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+		wantErr  bool
+	}{
+		{name: "no header", header: "", expected: ""},
+		{name: "sha-256", header: "sha-256=:" + b64Digest + ":", expected: hexDigest},
+		{name: "among other algorithms", header: "sha-512=:AAAA:, sha-256=:" + b64Digest + ":", expected: hexDigest},
+		{name: "upper case algorithm", header: "SHA-256=:" + b64Digest + ":", expected: hexDigest},
+		{name: "only other algorithms", header: "sha-512=:AAAA:", wantErr: true},
+		{name: "missing colons", header: "sha-256=" + b64Digest, wantErr: true},
+		{name: "wrong length", header: "sha-256=:AAAA:", wantErr: true},
+		{name: "not base64", header: "sha-256=:not base64:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContentDigest(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentDigest(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("parseContentDigest(%q) = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
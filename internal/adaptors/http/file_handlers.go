@@ -5,18 +5,30 @@ import (
 	"chat-backend-general/internal/domain"
 	"chat-backend-general/internal/usecases"
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// defaultSignedURLExpiry is used when a signing request does not ask for a specific lifetime.
+const defaultSignedURLExpiry = 15 * time.Minute
+
 type FileHandler struct {
-	fileUploadUseCase usecases.FileUploadUseCase
-	fileValidators    []domain.FileValidator
+	fileUploadUseCase     usecases.FileUploadUseCase
+	fileManagementUseCase usecases.FileManagementUseCase
+	signedUploadUseCase   usecases.SignedUploadUseCase
+	fileValidators        []domain.FileValidator
 }
 
-func NewFileHandler(fileUploadUseCase usecases.FileUploadUseCase, fileValidators []domain.FileValidator) *FileHandler {
-	return &FileHandler{fileUploadUseCase: fileUploadUseCase, fileValidators: fileValidators}
+func NewFileHandler(fileUploadUseCase usecases.FileUploadUseCase, fileManagementUseCase usecases.FileManagementUseCase, signedUploadUseCase usecases.SignedUploadUseCase, fileValidators []domain.FileValidator) *FileHandler {
+	return &FileHandler{
+		fileUploadUseCase:     fileUploadUseCase,
+		fileManagementUseCase: fileManagementUseCase,
+		signedUploadUseCase:   signedUploadUseCase,
+		fileValidators:        fileValidators,
+	}
 }
 
 func (f *FileHandler) UploadFile(c *gin.Context) {
@@ -28,6 +40,14 @@ func (f *FileHandler) UploadFile(c *gin.Context) {
 		return
 	}
 
+	// The digest of the file is sent as a checksum form field, or as the Content-Digest
+	// header of its form part or of the request, which then stands for the file's.
+	checksum, err := uploadChecksum(c.PostForm("checksum"), file.Header.Get("Content-Digest"), c.GetHeader("Content-Digest"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checksum", "details": err.Error()})
+		return
+	}
+
 	// Read file data
 	fileData, err := file.Open()
 	if err != nil {
@@ -36,29 +56,200 @@ func (f *FileHandler) UploadFile(c *gin.Context) {
 	}
 	defer fileData.Close()
 
-	contPath := uname + "/" + chatid + "/" + file.Filename
-	// Create domain.UploadedFile instance
+	// Create domain.UploadedFile instance; the use case derives its storage key
 	uploadedFile := domain.UploadedFile{
 		Name:        file.Filename,
 		ContentType: file.Header.Get("Content-Type"),
 		File:        fileData,
 		Size:        file.Size,
-		Path:        contPath,
+		Owner:       uname,
+		ChatID:      chatid,
+		Checksum:    checksum,
+	}
+
+	// Validate file, reporting every failure at once
+	if err := domain.ValidateFile(f.fileValidators, uploadedFile); err != nil {
+		validationFailed(c, err)
+		return
+	}
+
+	// Handle file upload using the use case
+	result, err := f.fileUploadUseCase.HandleFileUpload(context.Background(), uploadedFile)
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to upload file")})
+		return
+	}
+
+	c.JSON(http.StatusOK, uploadResponse(result))
+}
+
+// uploadResponse describes a stored upload to the client.
+func uploadResponse(result domain.UploadResult) gin.H {
+	response := gin.H{"message": "File uploaded successfully", "fileId": result.FileID, "path": result.Path,
+		"checksum": result.Checksum, "version": result.Version}
+	if result.TaskID != "" {
+		response["taskId"] = result.TaskID
+	}
+	return response
+}
+
+// ListFiles returns the files stored for a user's chat.
+func (f *FileHandler) ListFiles(c *gin.Context) {
+	files, err := f.fileManagementUseCase.ListFiles(c.Request.Context(), c.Param("username"), c.Param("chatid"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to list files")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+// GetFileRecord returns the metadata of a file by its ID.
+func (f *FileHandler) GetFileRecord(c *gin.Context) {
+	record, err := f.fileManagementUseCase.GetFileRecord(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to get file")})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// DownloadFile streams a file of a user's chat back to the client, with range
+// support so large documents can be previewed without downloading them whole.
+func (f *FileHandler) DownloadFile(c *gin.Context) {
+	username, chatID, filename := c.Param("username"), c.Param("chatid"), c.Param("filename")
+
+	info, err := f.fileManagementUseCase.StatFile(c.Request.Context(), username, chatID, filename)
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to download file")})
+		return
+	}
+
+	serveFile(c, info, func(rng *domain.ByteRange) (domain.StoredFile, error) {
+		return f.fileManagementUseCase.GetFile(c.Request.Context(), username, chatID, filename, rng)
+	})
+}
+
+// SignURL returns a short-lived URL for downloading or uploading a file directly
+// against the storage provider, so large transfers bypass this service. Uploads announce
+// their size and are recorded as pending until they are finalized.
+func (f *FileHandler) SignURL(c *gin.Context) {
+	var request struct {
+		Username    string `json:"username" binding:"required"`
+		ChatID      string `json:"chatid" binding:"required"`
+		Filename    string `json:"filename" binding:"required"`
+		Operation   string `json:"operation" binding:"required,oneof=download upload"`
+		ExpiresIn   int    `json:"expiresIn" binding:"omitempty,min=1,max=86400"` // seconds
+		Size        *int64 `json:"size" binding:"omitempty,min=0"`                // bytes, required for uploads
+		ContentType string `json:"contentType"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	expiry := defaultSignedURLExpiry
+	if request.ExpiresIn > 0 {
+		expiry = time.Duration(request.ExpiresIn) * time.Second
 	}
 
-	// Validate file
-	for _, validator := range f.fileValidators {
-		if err := validator.Validate(uploadedFile); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var signed domain.SignedURL
+	var err error
+	if domain.SignedURLOperation(request.Operation) == domain.SignedURLUpload {
+		if request.Size == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size is required for uploads"})
 			return
 		}
+		file := domain.UploadedFile{Name: request.Filename, ContentType: request.ContentType, Size: *request.Size}
+		if err := domain.ValidateFile(f.fileValidators, file); err != nil {
+			validationFailed(c, err)
+			return
+		}
+		signed, err = f.signedUploadUseCase.SignUpload(c.Request.Context(), request.Username, request.ChatID, file, expiry)
+	} else {
+		signed, err = f.fileManagementUseCase.SignURL(c.Request.Context(), request.Username, request.ChatID, request.Filename, expiry)
+	}
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to sign URL")})
+		return
 	}
 
-	// Handle file upload using the use case
-	if err := f.fileUploadUseCase.HandleFileUpload(context.Background(), uploadedFile); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+	c.JSON(http.StatusOK, signed)
+}
+
+// FinalizeUpload checks and stores a file uploaded to a signed URL. Until it is
+// finalized the upload is pending and cannot be downloaded.
+func (f *FileHandler) FinalizeUpload(c *gin.Context) {
+	result, err := f.signedUploadUseCase.FinalizeUpload(c.Request.Context(), c.Param("id"))
+	var failures domain.ValidationErrors
+	if errors.As(err, &failures) {
+		validationFailed(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to finalize upload")})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "File uploaded successfully"})
+	c.JSON(http.StatusOK, uploadResponse(result))
+}
+
+// DeleteFile removes a document from a user's chat, with all its versions. It can be
+// restored until it is purged.
+func (f *FileHandler) DeleteFile(c *gin.Context) {
+	err := f.fileManagementUseCase.DeleteFile(c.Request.Context(), c.Param("username"), c.Param("chatid"), c.Param("filename"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to delete file")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted successfully"})
+}
+
+// fileErrorStatus maps use case errors to HTTP status codes.
+func fileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPath), errors.Is(err, domain.ErrChecksumMismatch), errors.Is(err, domain.ErrChecksumAlgorithmUnsupported),
+		errors.Is(err, domain.ErrUploadSizeMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrFileNotFound), errors.Is(err, domain.ErrFileRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidSignature):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotSupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, domain.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrUploadOffsetMismatch), errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrVersionUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrUploadTooLarge), errors.Is(err, domain.ErrQuotaBytesExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrQuotaFilesExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrFileInfected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrScanFailed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// validationCode is the error code of responses listing ValidationErrors.
+const validationCode = "validation_failed"
+
+// validationFailed responds with every failure reported by domain.ValidateFile.
+func validationFailed(c *gin.Context, err error) {
+	var failures domain.ValidationErrors
+	errors.As(err, &failures)
+	c.JSON(http.StatusBadRequest, gin.H{"error": "File validation failed", "code": validationCode, "details": failures})
+}
+
+// fileErrorMessage exposes client errors as-is and hides internal ones behind fallback.
+func fileErrorMessage(err error, fallback string) string {
+	if fileErrorStatus(err) == http.StatusInternalServerError {
+		return fallback
+	}
+	return err.Error()
 }
@@ -0,0 +1,356 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-backend-general/config"
+	"chat-backend-general/internal/adaptors/repository"
+	"chat-backend-general/internal/adaptors/storage"
+	"chat-backend-general/internal/adaptors/validation"
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, domain.FileRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{Storage: config.StorageProvider{Provider: storage.ProviderLocal, RootDir: t.TempDir()}}
+	repo, err := storage.NewLocalStorageAdapter(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewLocalStorageAdapter() error = %v", err)
+	}
+
+	metadata := repository.NewMemoryFileMetadataRepository()
+	handler := NewFileHandler(usecases.NewFileUploadUseCase(repo, metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", "")), usecases.NewFileManagementUseCase(repo, metadata), nil, nil)
+	r := gin.New()
+	r.GET("/doc/:username/:chatid/:filename", handler.DownloadFile)
+	r.HEAD("/doc/:username/:chatid/:filename", handler.DownloadFile)
+	return r, repo
+}
+
+func TestFileHandler_DownloadFile(t *testing.T) {
+	r, repo := newTestRouter(t)
+	content := "0123456789abcdefghij"
+	err := repo.SaveFile(context.Background(), domain.UploadedFile{
+		Name: "doc.txt", File: strings.NewReader(content), Size: int64(len(content)), Path: "alice/chat-1/doc.txt",
+	})
+	if err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		headers      map[string]string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{name: "full download", method: http.MethodGet, status: http.StatusOK, body: content},
+		{name: "head", method: http.MethodHead, status: http.StatusOK, body: ""},
+		{name: "range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=5-9"}, status: http.StatusPartialContent, body: "56789", contentRange: "bytes 5-9/20"},
+		{name: "suffix range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=-3"}, status: http.StatusPartialContent, body: "hij", contentRange: "bytes 17-19/20"},
+		{name: "unsatisfiable range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=50-"}, status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */20"},
+		{name: "stale if-range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=5-9", "If-Range": `"stale"`}, status: http.StatusOK, body: content},
+		{name: "if-none-match wildcard", method: http.MethodGet, headers: map[string]string{"If-None-Match": "*"}, status: http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/doc/alice/chat-1/doc.txt", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %q)", w.Code, tt.status, w.Body.String())
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+		})
+	}
+}
+
+func TestFileHandler_DownloadFileNotFound(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/doc/alice/chat-1/missing.pdf", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestFileHandler_SignedURLRoundTrip(t *testing.T) {
+	r, repo := newTestRouter(t)
+	local := repo.(*storage.LocalStorageAdapter)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	quota := usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil)
+	scan := usecases.NewFileScanUseCase(repo, nil, "")
+	ingest := usecases.NewFileIngestUseCase(metadata, nil, "", "")
+	signedUpload := usecases.NewSignedUploadUseCase(repo, metadata, quota, scan, ingest, nil, time.Hour)
+	handler := NewFileHandler(usecases.NewFileUploadUseCase(repo, metadata, quota, scan, ingest), usecases.NewFileManagementUseCase(repo, metadata), signedUpload, nil)
+	signedHandler := NewSignedStorageHandler(repo, local, signedUpload)
+	r.POST("/doc/sign", handler.SignURL)
+	r.GET(storage.LocalSignedURLPrefix+"*path", signedHandler.ServeSignedURL)
+	r.PUT(storage.LocalSignedURLPrefix+"*path", signedHandler.ServeSignedURL)
+
+	sign := func(filename, operation, extra string) domain.SignedURL {
+		t.Helper()
+		body := `{"username":"alice","chatid":"chat-1","filename":"` + filename + `","operation":"` + operation + `","expiresIn":60` + extra + `}`
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/sign", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("sign %s status = %d, want %d (body %q)", operation, w.Code, http.StatusOK, w.Body.String())
+		}
+		var signed domain.SignedURL
+		if err := json.Unmarshal(w.Body.Bytes(), &signed); err != nil {
+			t.Fatalf("sign %s response: %v", operation, err)
+		}
+		return signed
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/sign", strings.NewReader(`{"username":"alice","chatid":"chat-1","filename":"big.pdf","operation":"upload"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("sign upload without size status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	upload := sign("big.pdf", "upload", `,"size":14,"contentType":"application/pdf"`)
+	if !strings.HasPrefix(upload.Path, "alice/chat-1/") || !strings.HasSuffix(upload.Path, "_big.pdf") || upload.FileID == "" {
+		t.Errorf("signed upload = %q (file %q), want alice/chat-1/<id>_big.pdf with its file ID", upload.Path, upload.FileID)
+	}
+	if record, err := metadata.GetFileRecord(context.Background(), upload.FileID); err != nil || record.Status != domain.FileStatusPending {
+		t.Errorf("record of signed upload = %q, %v, want %q", record.Status, err, domain.FileStatusPending)
+	}
+
+	// Content of another size than signed for is refused.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(upload.Method, upload.URL, strings.NewReader("%PDF-1.7 larger")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("oversized signed upload status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	req := httptest.NewRequest(upload.Method, upload.URL, strings.NewReader("%PDF-1.7 large"))
+	req.Header.Set("Content-Type", "application/pdf")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signed upload status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if record, err := metadata.GetFileRecord(context.Background(), upload.FileID); err != nil || record.Status != domain.FileStatusStored || record.Checksum == "" {
+		t.Errorf("record of received upload = %q %q, %v, want %q with its checksum", record.Status, record.Checksum, err, domain.FileStatusStored)
+	}
+
+	// The signed URL cannot be used again once the upload is stored.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(upload.Method, upload.URL, strings.NewReader("%PDF-1.7 again")))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second signed upload status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	download := sign(upload.Path[len("alice/chat-1/"):], "download", "")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(download.Method, download.URL, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF-1.7 large" {
+		t.Errorf("signed download = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, "%PDF-1.7 large")
+	}
+
+	// A download signature must not authorize an upload to the same path.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, download.URL, strings.NewReader("overwrite")))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("upload with download signature status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestFileHandler_FinalizeUpload(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	quota := usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil)
+	scan := usecases.NewFileScanUseCase(repo, nil, "")
+	ingest := usecases.NewFileIngestUseCase(metadata, nil, "", "")
+	validators := []domain.FileValidator{validation.NewFileContentValidator([]string{"application/pdf"})}
+	signedUpload := usecases.NewSignedUploadUseCase(repo, metadata, quota, scan, ingest, validators, time.Hour)
+	handler := NewFileHandler(usecases.NewFileUploadUseCase(repo, metadata, quota, scan, ingest), usecases.NewFileManagementUseCase(repo, metadata), signedUpload, validators)
+	r.POST("/doc/files/:id/finalize", handler.FinalizeUpload)
+
+	tests := []struct {
+		name    string
+		content string // stored at the signed path, as the storage provider would; none when empty
+		status  int
+		record  domain.FileStatus
+	}{
+		{name: "stored", content: "%PDF-1.7 large", status: http.StatusOK, record: domain.FileStatusStored},
+		{name: "not uploaded yet", status: http.StatusNotFound, record: domain.FileStatusPending},
+		{name: "other size", content: "%PDF-1.7 larger", status: http.StatusBadRequest, record: domain.FileStatusFailed},
+		{name: "rejected by validators", content: "MZ not a pdf..", status: http.StatusBadRequest, record: domain.FileStatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			signed, err := signedUpload.SignUpload(ctx, "alice", "chat-1", domain.UploadedFile{Name: "big.pdf", ContentType: "application/pdf", Size: 14}, time.Minute)
+			if err != nil {
+				t.Fatalf("SignUpload() error = %v", err)
+			}
+			if tt.content != "" {
+				err := repo.SaveFile(ctx, domain.UploadedFile{File: strings.NewReader(tt.content), Size: int64(len(tt.content)), Path: signed.Path})
+				if err != nil {
+					t.Fatalf("SaveFile() error = %v", err)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/files/"+signed.FileID+"/finalize", nil))
+			if w.Code != tt.status {
+				t.Errorf("finalize status = %d, want %d (body %q)", w.Code, tt.status, w.Body.String())
+			}
+			record, err := metadata.GetFileRecord(ctx, signed.FileID)
+			if err != nil || record.Status != tt.record {
+				t.Errorf("record after finalize = %q, %v, want %q", record.Status, err, tt.record)
+			}
+			if _, err := repo.StatFile(ctx, signed.Path); (err == nil) != (tt.record == domain.FileStatusStored) {
+				t.Errorf("StatFile() after finalize error = %v, want content kept only when stored", err)
+			}
+
+			// A finalized upload cannot be finalized again.
+			if tt.record != domain.FileStatusPending {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/files/"+signed.FileID+"/finalize", nil))
+				if w.Code != http.StatusNotFound {
+					t.Errorf("second finalize status = %d, want %d", w.Code, http.StatusNotFound)
+				}
+			}
+		})
+	}
+}
+
+func TestFileHandler_UploadReportsAllValidationErrors(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	validators := []domain.FileValidator{
+		validation.NewFileSizeValidator(4, nil),
+		validation.NewFileExtensionValidator([]string{".pdf"}),
+		validation.NewFileTypeValidator([]string{"application/pdf"}),
+	}
+	handler := NewFileHandler(usecases.NewFileUploadUseCase(repo, metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", "")),
+		usecases.NewFileManagementUseCase(repo, metadata), nil, validators)
+	r.POST("/doc/upload", handler.UploadFile)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("username", "alice")
+	form.WriteField("chatid", "chat-1")
+	part, _ := form.CreateFormFile("file", "setup.exe")
+	part.Write([]byte("MZ not a pdf"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/doc/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response struct {
+		Code    string                   `json:"code"`
+		Details []domain.ValidationError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("upload response: %v", err)
+	}
+	if w.Code != http.StatusBadRequest || response.Code != validationCode {
+		t.Fatalf("upload = %d %q, want %d %q", w.Code, response.Code, http.StatusBadRequest, validationCode)
+	}
+	var codes []string
+	for _, detail := range response.Details {
+		codes = append(codes, detail.Code)
+	}
+	want := []string{domain.ValidationCodeFileTooLarge, domain.ValidationCodeExtensionNotAllowed, domain.ValidationCodeTypeNotAllowed}
+	if !slices.Equal(codes, want) {
+		t.Errorf("validation codes = %v, want %v", codes, want)
+	}
+}
+
+func TestFileHandler_UploadVerifiesContentDigest(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	handler := NewFileHandler(usecases.NewFileUploadUseCase(repo, metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", "")),
+		usecases.NewFileManagementUseCase(repo, metadata), nil, nil)
+	r.POST("/doc/upload", handler.UploadFile)
+
+	// SHA-256 of "hello".
+	const digest = "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:"
+	const checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	tests := []struct {
+		name          string
+		content       string
+		digest        string
+		checksum      string
+		requestDigest string
+		status        int
+	}{
+		{name: "matching", content: "hello", digest: digest, status: http.StatusOK},
+		{name: "no digest", content: "hello", status: http.StatusOK},
+		{name: "mismatch", content: "hello!", digest: digest, status: http.StatusBadRequest},
+		{name: "malformed", content: "hello", digest: "sha-256=hello", status: http.StatusBadRequest},
+		{name: "checksum field", content: "hello", checksum: strings.ToUpper(checksum), status: http.StatusOK},
+		{name: "checksum field mismatch", content: "hello!", checksum: checksum, status: http.StatusBadRequest},
+		{name: "malformed checksum field", content: "hello", checksum: "hello", status: http.StatusBadRequest},
+		{name: "request digest", content: "hello", requestDigest: digest, status: http.StatusOK},
+		{name: "request digest mismatch", content: "hello!", requestDigest: digest, status: http.StatusBadRequest},
+		{name: "conflicting digests", content: "hello", digest: digest, requestDigest: "sha-256=:" + strings.Repeat("A", 43) + "=:", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			form.WriteField("username", "alice")
+			form.WriteField("chatid", "chat-1")
+			if tt.checksum != "" {
+				form.WriteField("checksum", tt.checksum)
+			}
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition", `form-data; name="file"; filename="hello.txt"`)
+			header.Set("Content-Type", "text/plain")
+			if tt.digest != "" {
+				header.Set("Content-Digest", tt.digest)
+			}
+			part, _ := form.CreatePart(header)
+			part.Write([]byte(tt.content))
+			form.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/doc/upload", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			if tt.requestDigest != "" {
+				req.Header.Set("Content-Digest", tt.requestDigest)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("upload status = %d, want %d (body %q)", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// internal/adaptors/http/quota_handlers.go
+package http
+
+import (
+	"chat-backend-general/internal/usecases"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaHandler exposes the storage usage of users.
+type QuotaHandler struct {
+	quotaUseCase usecases.QuotaUseCase
+}
+
+func NewQuotaHandler(quotaUseCase usecases.QuotaUseCase) *QuotaHandler {
+	return &QuotaHandler{quotaUseCase: quotaUseCase}
+}
+
+// GetUsage returns the usage and quota limits of a user, and of one of its chats
+// when the chatid query parameter is given.
+func (q *QuotaHandler) GetUsage(c *gin.Context) {
+	report, err := q.quotaUseCase.GetUsage(c.Request.Context(), c.Param("username"), c.Query("chatid"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to get usage")})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
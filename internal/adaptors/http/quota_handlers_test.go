@@ -0,0 +1,66 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chat-backend-general/internal/adaptors/repository"
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+)
+
+func TestQuotaHandler_UploadAndUsage(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	quota := usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{MaxBytes: 8, MaxFiles: 2}, nil)
+	handler := NewFileHandler(usecases.NewFileUploadUseCase(repo, metadata, quota, usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", "")), usecases.NewFileManagementUseCase(repo, metadata), nil, nil)
+	r.POST("/doc/upload", handler.UploadFile)
+	r.GET("/doc/usage/:username", NewQuotaHandler(quota).GetUsage)
+
+	upload := func(content string) int {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("username", "alice")
+		form.WriteField("chatid", "chat-1")
+		part, _ := form.CreateFormFile("file", "notes.txt")
+		part.Write([]byte(content))
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/doc/upload", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := upload("12345"); code != http.StatusOK {
+		t.Fatalf("first upload status = %d, want %d", code, http.StatusOK)
+	}
+	if code := upload("123456"); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("upload over byte quota status = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	if code := upload("1"); code != http.StatusOK {
+		t.Fatalf("second upload status = %d, want %d", code, http.StatusOK)
+	}
+	if code := upload("1"); code != http.StatusForbidden {
+		t.Errorf("upload over file quota status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/doc/usage/alice?chatid=chat-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("usage status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var report domain.QuotaReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("usage response: %v", err)
+	}
+	if report.Usage != (domain.Usage{Files: 2, Bytes: 6}) || report.ChatUsage == nil || report.Limits.MaxBytes != 8 {
+		t.Errorf("usage = %+v, want 2 files of 6 bytes with a limit of 8 bytes", report)
+	}
+}
@@ -0,0 +1,111 @@
+// internal/adaptors/http/serve_file.go
+package http
+
+import (
+	"chat-backend-general/internal/domain"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveFile writes a stored file to the response. It supports HEAD requests,
+// conditional requests on the ETag and single byte ranges; open is only called
+// once the response status is known and the body is actually needed.
+func serveFile(c *gin.Context, info domain.FileInfo, open func(rng *domain.ByteRange) (domain.StoredFile, error)) {
+	etag := quoteETag(info.ETag)
+	header := c.Writer.Header()
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": info.Name}))
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !info.LastModified.IsZero() {
+		header.Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if etag != "" && etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// A stale If-Range means the client's partial copy is outdated, so send the whole file.
+	rangeHeader := c.GetHeader("Range")
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && !ifRangeMatches(ifRange, etag, info.LastModified) {
+		rangeHeader = ""
+	}
+
+	rng, err := parseByteRange(rangeHeader, info.Size)
+	if errors.Is(err, errRangeNotSatisfiable) {
+		header.Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+
+	status, length := http.StatusOK, info.Size
+	if rng != nil {
+		status, length = http.StatusPartialContent, rng.Length
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", rng.Offset, rng.Offset+rng.Length-1, info.Size))
+	}
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+
+	stored, err := open(rng)
+	if err != nil {
+		header.Del("Content-Length")
+		header.Del("Content-Range")
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to download file")})
+		return
+	}
+	defer stored.Content.Close()
+
+	c.Status(status)
+	// Headers are already sent, so a failure here can only cut the response short.
+	_, _ = io.Copy(c.Writer, stored.Content)
+}
+
+// quoteETag returns etag as a quoted HTTP entity tag.
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag (weak comparison).
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ifRangeMatches evaluates an If-Range header, which holds either an entity tag or an HTTP date.
+func ifRangeMatches(header, etag string, lastModified time.Time) bool {
+	if strings.HasPrefix(header, `"`) {
+		return header == etag
+	}
+	if t, err := http.ParseTime(header); err == nil && !lastModified.IsZero() {
+		return lastModified.Truncate(time.Second).Equal(t)
+	}
+	return false
+}
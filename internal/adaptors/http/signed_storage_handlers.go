@@ -0,0 +1,84 @@
+// internal/adaptors/http/signed_storage_handlers.go
+package http
+
+import (
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedStorageHandler serves signed URLs for storage backends that cannot serve
+// them on their own, such as the local filesystem adapter.
+type SignedStorageHandler struct {
+	fileRepository      domain.FileRepository
+	verifier            domain.SignedURLVerifier
+	signedUploadUseCase usecases.SignedUploadUseCase
+}
+
+func NewSignedStorageHandler(fileRepository domain.FileRepository, verifier domain.SignedURLVerifier, signedUploadUseCase usecases.SignedUploadUseCase) *SignedStorageHandler {
+	return &SignedStorageHandler{fileRepository: fileRepository, verifier: verifier, signedUploadUseCase: signedUploadUseCase}
+}
+
+// ServeSignedURL handles GET/HEAD (download) and PUT (upload) requests on a signed URL.
+// Uploads are finalized as soon as their content has been received.
+func (h *SignedStorageHandler) ServeSignedURL(c *gin.Context) {
+	path := strings.TrimPrefix(c.Param("path"), "/")
+
+	op := domain.SignedURLDownload
+	if c.Request.Method == http.MethodPut {
+		op = domain.SignedURLUpload
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	var size int64
+	if err == nil && op == domain.SignedURLUpload {
+		size, err = strconv.ParseInt(c.Query("size"), 10, 64)
+	}
+	if err != nil || c.Query("op") != string(op) {
+		c.JSON(http.StatusForbidden, gin.H{"error": domain.ErrInvalidSignature.Error()})
+		return
+	}
+	if err := h.verifier.VerifySignedURL(path, op, expires, size, c.Query("sig")); err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to verify signature")})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if op == domain.SignedURLUpload {
+		if c.Request.ContentLength >= 0 && c.Request.ContentLength != size {
+			c.JSON(http.StatusBadRequest, gin.H{"error": domain.ErrUploadSizeMismatch.Error()})
+			return
+		}
+		file := domain.UploadedFile{
+			Name:        path[strings.LastIndex(path, "/")+1:],
+			ContentType: c.ContentType(),
+			File:        c.Request.Body,
+			Size:        size,
+		}
+		result, err := h.signedUploadUseCase.ReceiveUpload(ctx, path, file)
+		var failures domain.ValidationErrors
+		if errors.As(err, &failures) {
+			validationFailed(c, err)
+			return
+		}
+		if err != nil {
+			c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to upload file")})
+			return
+		}
+		c.JSON(http.StatusCreated, uploadResponse(result))
+		return
+	}
+
+	info, err := h.fileRepository.StatFile(ctx, path)
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to download file")})
+		return
+	}
+	serveFile(c, info, func(rng *domain.ByteRange) (domain.StoredFile, error) {
+		return h.fileRepository.GetFile(ctx, path, rng)
+	})
+}
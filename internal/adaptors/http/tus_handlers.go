@@ -0,0 +1,261 @@
+// internal/adaptors/http/tus_handlers.go
+package http
+
+import (
+	"bufio"
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tusVersion is the version of the tus resumable upload protocol spoken by TusHandler.
+const tusVersion = "1.0.0"
+
+// fileIDHeader carries the ID of the file record created for a resumable upload.
+const fileIDHeader = "X-File-Id"
+
+// taskIDHeader carries the ID of the ingestion task published once an upload completed.
+const taskIDHeader = "X-Task-Id"
+
+// statusChecksumMismatch is the status the tus checksum extension responds with when a
+// body does not match its Upload-Checksum.
+const statusChecksumMismatch = 460
+
+// TusHandler implements the core, creation, expiration, termination and checksum parts
+// of the tus 1.0 resumable upload protocol (https://tus.io/protocols/resumable-upload).
+type TusHandler struct {
+	resumableUploadUseCase usecases.ResumableUploadUseCase
+	fileValidators         []domain.FileValidator
+	basePath               string
+}
+
+func NewTusHandler(resumableUploadUseCase usecases.ResumableUploadUseCase, fileValidators []domain.FileValidator, basePath string) *TusHandler {
+	return &TusHandler{
+		resumableUploadUseCase: resumableUploadUseCase,
+		fileValidators:         fileValidators,
+		basePath:               strings.TrimSuffix(basePath, "/"),
+	}
+}
+
+// Options advertises the supported protocol version and extensions.
+func (t *TusHandler) Options(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,expiration,termination,checksum")
+	c.Header("Tus-Checksum-Algorithm", strings.Join(domain.ChecksumAlgorithms, ","))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload starts an upload. The client announces the size in Upload-Length and
+// passes filename, filetype, username and chatid in Upload-Metadata.
+func (t *TusHandler) CreateUpload(c *gin.Context) {
+	if !t.checkVersion(c) {
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length header is required"})
+		return
+	}
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Metadata header"})
+		return
+	}
+
+	file := domain.UploadedFile{
+		Name:        metadata["filename"],
+		ContentType: metadata["filetype"],
+		Size:        length,
+	}
+	if err := domain.ValidateFile(t.fileValidators, file); err != nil {
+		validationFailed(c, err)
+		return
+	}
+
+	session, err := t.resumableUploadUseCase.CreateUpload(c.Request.Context(), metadata["username"], metadata["chatid"], file)
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to create upload")})
+		return
+	}
+
+	c.Header("Location", t.basePath+"/"+session.ID)
+	c.Header(fileIDHeader, session.FileID)
+	c.Header("Upload-Expires", session.ExpiresAt.Format(http.TimeFormat))
+	if session.TaskID != "" {
+		c.Header(taskIDHeader, session.TaskID) // Empty uploads complete on creation
+	}
+	c.Status(http.StatusCreated)
+}
+
+// GetUploadOffset reports how many bytes of an upload have been received.
+func (t *TusHandler) GetUploadOffset(c *gin.Context) {
+	if !t.checkVersion(c) {
+		return
+	}
+
+	session, err := t.resumableUploadUseCase.GetUpload(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Status(fileErrorStatus(err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Length, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload appends the request body to an upload at Upload-Offset. A body sent with an
+// Upload-Checksum that it does not match is discarded.
+func (t *TusHandler) PatchUpload(c *gin.Context) {
+	if !t.checkVersion(c) {
+		return
+	}
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+	checksum, err := parseUploadChecksum(c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Checksum header"})
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if offset == 0 && len(t.fileValidators) > 0 {
+		// Content validators sniff the leading bytes, which only arrive with the first chunk.
+		// bufio's default 4 KiB buffer lets them peek without consuming the body.
+		buffered := bufio.NewReader(body)
+		if !t.validateFirstChunk(c, buffered) {
+			return
+		}
+		body = buffered
+	}
+
+	session, err := t.resumableUploadUseCase.WriteChunk(c.Request.Context(), c.Param("id"), offset, body, checksum)
+	if session.ID != "" {
+		c.Header(fileIDHeader, session.FileID)
+		c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+		c.Header("Upload-Expires", session.ExpiresAt.Format(http.TimeFormat))
+	}
+	if session.TaskID != "" {
+		c.Header(taskIDHeader, session.TaskID)
+	}
+	var failures domain.ValidationErrors
+	if errors.As(err, &failures) {
+		// The assembled file failed validation.
+		validationFailed(c, err)
+		return
+	}
+	if errors.Is(err, domain.ErrChecksumMismatch) {
+		c.JSON(statusChecksumMismatch, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to store upload chunk")})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TerminateUpload discards an unfinished upload.
+func (t *TusHandler) TerminateUpload(c *gin.Context) {
+	if !t.checkVersion(c) {
+		return
+	}
+
+	if err := t.resumableUploadUseCase.AbortUpload(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to terminate upload")})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// validateFirstChunk runs the file validators against the start of an upload and discards
+// the upload when they reject it.
+func (t *TusHandler) validateFirstChunk(c *gin.Context, body io.Reader) bool {
+	session, err := t.resumableUploadUseCase.GetUpload(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to store upload chunk")})
+		return false
+	}
+	if session.Offset != 0 {
+		return true
+	}
+
+	file := domain.UploadedFile{
+		Name:        session.Name,
+		ContentType: session.ContentType,
+		File:        body,
+		Size:        session.Length,
+	}
+	if err := domain.ValidateFile(t.fileValidators, file); err != nil {
+		_ = t.resumableUploadUseCase.AbortUpload(c.Request.Context(), session.ID)
+		validationFailed(c, err)
+		return false
+	}
+	return true
+}
+
+// checkVersion sets the Tus-Resumable response header and rejects clients speaking another version.
+func (t *TusHandler) checkVersion(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// parseUploadChecksum decodes an Upload-Checksum header: an algorithm and the base64-encoded
+// checksum, separated by a space. It returns nil when there is no header.
+func parseUploadChecksum(header string) (*domain.UploadChecksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, errors.New("upload checksum must be an algorithm and a base64-encoded checksum")
+	}
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UploadChecksum{Algorithm: algorithm, Sum: sum}, nil
+}
+
+// parseTusMetadata decodes an Upload-Metadata header: comma-separated pairs of a key
+// and an optional base64-encoded value.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,399 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/adaptors/repository"
+	"chat-backend-general/internal/adaptors/validation"
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTusHandler_ResumableUpload(t *testing.T) {
+	r, repo := newTestRouter(t)
+	// A tiny chunk size makes every PATCH span several staged chunks.
+	metadata := repository.NewMemoryFileMetadataRepository()
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""), nil, 4, time.Hour)
+	validators := []domain.FileValidator{validation.NewFileContentValidator([]string{"text/plain"})}
+	tus := NewTusHandler(useCase, validators, "/doc/uploads")
+	r.POST("/doc/uploads", tus.CreateUpload)
+	r.HEAD("/doc/uploads/:id", tus.GetUploadOffset)
+	r.PATCH("/doc/uploads/:id", tus.PatchUpload)
+
+	do := func(method, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Tus-Resumable", tusVersion)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	content := "hello resumable world"
+	w := do(http.MethodPost, "/doc/uploads", "", map[string]string{
+		"Upload-Length":   "21",
+		"Upload-Metadata": "filename " + b64("notes.txt") + ",filetype " + b64("text/plain") + ",username " + b64("alice") + ",chatid " + b64("chat-1"),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "/doc/uploads/") {
+		t.Fatalf("create Location = %q, want /doc/uploads/<id>", location)
+	}
+
+	patch := func(offset, body string) *httptest.ResponseRecorder {
+		return do(http.MethodPatch, location, body, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": offset,
+		})
+	}
+
+	if w := patch("0", content[:10]); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "10" {
+		t.Fatalf("first patch = %d offset %q, want %d offset 10", w.Code, w.Header().Get("Upload-Offset"), http.StatusNoContent)
+	}
+	if w := do(http.MethodHead, location, "", nil); w.Header().Get("Upload-Offset") != "10" || w.Header().Get("Upload-Length") != "21" {
+		t.Errorf("head offset/length = %q/%q, want 10/21", w.Header().Get("Upload-Offset"), w.Header().Get("Upload-Length"))
+	}
+	if w := patch("3", content[3:]); w.Code != http.StatusConflict {
+		t.Errorf("patch at stale offset status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := patch("10", content[10:]); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "21" {
+		t.Fatalf("final patch = %d offset %q, want %d offset 21", w.Code, w.Header().Get("Upload-Offset"), http.StatusNoContent)
+	}
+
+	fileID := w.Header().Get(fileIDHeader)
+	stored, err := repo.GetFile(context.Background(), "alice/chat-1/"+fileID+"_notes.txt", nil)
+	if err != nil {
+		t.Fatalf("GetFile() after upload error = %v", err)
+	}
+	defer stored.Content.Close()
+	got, _ := io.ReadAll(stored.Content)
+	if string(got) != content {
+		t.Errorf("uploaded content = %q, want %q", got, content)
+	}
+}
+
+func TestTusHandler_RejectsOversizedBody(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""), nil, 4, time.Hour)
+	tus := NewTusHandler(useCase, nil, "/doc/uploads")
+	r.POST("/doc/uploads", tus.CreateUpload)
+	r.HEAD("/doc/uploads/:id", tus.GetUploadOffset)
+	r.PATCH("/doc/uploads/:id", tus.PatchUpload)
+
+	do := func(method, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Tus-Resumable", tusVersion)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	w := do(http.MethodPost, "/doc/uploads", "", map[string]string{
+		"Upload-Length":   "10",
+		"Upload-Metadata": "filename " + b64("notes.txt") + ",username " + b64("alice") + ",chatid " + b64("chat-1"),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	patch := func(offset, body string) *httptest.ResponseRecorder {
+		return do(http.MethodPatch, location, body, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": offset,
+		})
+	}
+
+	if w := patch("0", "0123456789 and more"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized patch status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	// Only the chunks before the last one are kept, so the upload is not complete.
+	w = do(http.MethodHead, location, "", nil)
+	if w.Header().Get("Upload-Offset") != "8" {
+		t.Fatalf("head offset after oversized patch = %q, want 8", w.Header().Get("Upload-Offset"))
+	}
+	if w := patch("10", ""); w.Code != http.StatusConflict {
+		t.Errorf("empty patch at the length status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	w = patch("8", "89")
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "10" {
+		t.Fatalf("final patch = %d offset %q, want %d offset 10", w.Code, w.Header().Get("Upload-Offset"), http.StatusNoContent)
+	}
+	stored, err := repo.GetFile(context.Background(), "alice/chat-1/"+w.Header().Get(fileIDHeader)+"_notes.txt", nil)
+	if err != nil {
+		t.Fatalf("GetFile() after upload error = %v", err)
+	}
+	defer stored.Content.Close()
+	if got, _ := io.ReadAll(stored.Content); string(got) != "0123456789" {
+		t.Errorf("uploaded content = %q, want %q", got, "0123456789")
+	}
+}
+
+func TestTusHandler_RejectsSpoofedContent(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""), nil, 4, time.Hour)
+	validators := []domain.FileValidator{validation.NewFileContentValidator([]string{"application/pdf"})}
+	tus := NewTusHandler(useCase, validators, "/doc/uploads")
+	r.POST("/doc/uploads", tus.CreateUpload)
+	r.HEAD("/doc/uploads/:id", tus.GetUploadOffset)
+	r.PATCH("/doc/uploads/:id", tus.PatchUpload)
+
+	do := func(method, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Tus-Resumable", tusVersion)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	exe := "MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00"
+	w := do(http.MethodPost, "/doc/uploads", "", map[string]string{
+		"Upload-Length":   "16",
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("report.pdf")) + ",username " + base64.StdEncoding.EncodeToString([]byte("alice")) + ",chatid " + base64.StdEncoding.EncodeToString([]byte("chat-1")),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+
+	w = do(http.MethodPatch, location, exe, map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("patch with executable content status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := do(http.MethodHead, location, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("head after rejected content status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestTusHandler_ValidatesAssembledUpload(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	validators := []domain.FileValidator{validation.NewFileArchiveValidator(0, 0, 0, false)}
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil),
+		usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""), validators, 4, time.Hour)
+	tus := NewTusHandler(useCase, validators, "/doc/uploads")
+	r.POST("/doc/uploads", tus.CreateUpload)
+	r.PATCH("/doc/uploads/:id", tus.PatchUpload)
+
+	var document bytes.Buffer
+	archive := zip.NewWriter(&document)
+	archive.Create("word/document.xml")
+	archive.Create("word/vbaProject.bin")
+	archive.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/doc/uploads", nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Upload-Length", strconv.Itoa(document.Len()))
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("letter.docx"))+",username "+base64.StdEncoding.EncodeToString([]byte("alice"))+",chatid "+base64.StdEncoding.EncodeToString([]byte("chat-1")))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	fileID := w.Header().Get(fileIDHeader)
+
+	req = httptest.NewRequest(http.MethodPatch, w.Header().Get("Location"), &document)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), domain.ValidationCodeMacrosNotAllowed) {
+		t.Errorf("patch with macro document = %d %s, want %d with code %q", w.Code, w.Body.String(), http.StatusBadRequest, domain.ValidationCodeMacrosNotAllowed)
+	}
+
+	record, err := metadata.GetFileRecord(context.Background(), fileID)
+	if err != nil {
+		t.Fatalf("GetFileRecord(%q) error = %v", fileID, err)
+	}
+	if record.Status != domain.FileStatusRejected {
+		t.Errorf("record status = %q, want %q", record.Status, domain.FileStatusRejected)
+	}
+	if _, err := repo.StatFile(context.Background(), record.StoragePath); err == nil {
+		t.Errorf("StatFile(%q) error = nil, want rejected file deleted", record.StoragePath)
+	}
+}
+
+// infectedScanner reports every file as infected.
+type infectedScanner struct{}
+
+func (infectedScanner) Scan(ctx context.Context, content io.Reader) (domain.ScanResult, error) {
+	return domain.ScanResult{Verdict: domain.ScanVerdictInfected, Signature: "Test-Virus"}, nil
+}
+
+func TestTusHandler_RejectsInfectedUpload(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	scan := usecases.NewFileScanUseCase(repo, infectedScanner{}, domain.ScanActionReject)
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), scan, usecases.NewFileIngestUseCase(metadata, nil, "", ""), nil, 4, time.Hour)
+	tus := NewTusHandler(useCase, nil, "/doc/uploads")
+	r.POST("/doc/uploads", tus.CreateUpload)
+	r.PATCH("/doc/uploads/:id", tus.PatchUpload)
+
+	req := httptest.NewRequest(http.MethodPost, "/doc/uploads", nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Upload-Length", "5")
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("notes.txt"))+",username "+base64.StdEncoding.EncodeToString([]byte("alice"))+",chatid "+base64.StdEncoding.EncodeToString([]byte("chat-1")))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	fileID := w.Header().Get(fileIDHeader)
+
+	req = httptest.NewRequest(http.MethodPatch, w.Header().Get("Location"), strings.NewReader("hello"))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("patch with infected content status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	record, err := metadata.GetFileRecord(context.Background(), fileID)
+	if err != nil {
+		t.Fatalf("GetFileRecord(%q) error = %v", fileID, err)
+	}
+	if record.Status != domain.FileStatusRejected || record.ScanSignature != "Test-Virus" {
+		t.Errorf("record status, signature = %q, %q, want %q, %q", record.Status, record.ScanSignature, domain.FileStatusRejected, "Test-Virus")
+	}
+	if _, err := repo.StatFile(context.Background(), record.StoragePath); err == nil {
+		t.Errorf("StatFile(%q) error = nil, want infected file deleted", record.StoragePath)
+	}
+}
+
+func TestTusHandler_RequiresProtocolVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tus := NewTusHandler(nil, nil, "/doc/uploads")
+	r := gin.New()
+	r.POST("/doc/uploads", tus.CreateUpload)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/uploads", nil))
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("Tus-Version") != tusVersion {
+		t.Errorf("status = %d, Tus-Version = %q, want %d and %q", w.Code, w.Header().Get("Tus-Version"), http.StatusPreconditionFailed, tusVersion)
+	}
+}
+
+func TestTusHandler_ExpiredUploadReleasesQuota(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""), nil, 4, time.Millisecond)
+	r.POST("/doc/uploads", NewTusHandler(useCase, nil, "/doc/uploads").CreateUpload)
+
+	req := httptest.NewRequest(http.MethodPost, "/doc/uploads", nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Upload-Length", "100")
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("notes.txt"))+",username "+base64.StdEncoding.EncodeToString([]byte("alice"))+",chatid "+base64.StdEncoding.EncodeToString([]byte("chat-1")))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if usage, _ := metadata.GetUsage(context.Background(), "alice", ""); usage.Files != 1 {
+		t.Fatalf("usage while pending = %+v, want 1 file", usage)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	failed, err := useCase.FailExpiredUploads(context.Background())
+	if err != nil || failed != 1 {
+		t.Fatalf("FailExpiredUploads() = %d, %v, want 1, nil", failed, err)
+	}
+	if usage, _ := metadata.GetUsage(context.Background(), "alice", ""); usage.Files != 0 || usage.Bytes != 0 {
+		t.Errorf("usage after expiry = %+v, want none", usage)
+	}
+}
+
+func TestTusHandler_UploadChecksum(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	useCase := usecases.NewResumableUploadUseCase(repo, repository.NewMemoryUploadSessionStore(), metadata, usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""), nil, 4, time.Hour)
+	tus := NewTusHandler(useCase, nil, "/doc/uploads")
+	r.POST("/doc/uploads", tus.CreateUpload)
+	r.PATCH("/doc/uploads/:id", tus.PatchUpload)
+
+	do := func(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Tus-Resumable", tusVersion)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	sha256Checksum := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return "sha256 " + base64.StdEncoding.EncodeToString(sum[:])
+	}
+	sha1Sum := sha1.Sum([]byte("world"))
+
+	w := do(http.MethodPost, "/doc/uploads", "", map[string]string{
+		"Upload-Length":   "11",
+		"Upload-Metadata": "filename " + b64("notes.txt") + ",username " + b64("alice") + ",chatid " + b64("chat-1"),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d (body %q)", w.Code, http.StatusCreated, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+
+	tests := []struct {
+		name     string
+		offset   string
+		body     string
+		checksum string
+		status   int
+		want     string // Upload-Offset after the request
+	}{
+		{name: "mismatch", offset: "0", body: "hello ", checksum: sha256Checksum("hello!"), status: statusChecksumMismatch, want: "0"},
+		{name: "unsupported algorithm", offset: "0", body: "hello ", checksum: "md5 " + b64("0123456789abcdef"), status: http.StatusBadRequest},
+		{name: "malformed", offset: "0", body: "hello ", checksum: "sha256", status: http.StatusBadRequest},
+		{name: "matching", offset: "0", body: "hello ", checksum: sha256Checksum("hello "), status: http.StatusNoContent, want: "6"},
+		{name: "matching sha1", offset: "6", body: "world", checksum: "sha1 " + base64.StdEncoding.EncodeToString(sha1Sum[:]), status: http.StatusNoContent, want: "11"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(http.MethodPatch, location, tt.body, map[string]string{
+				"Content-Type":    "application/offset+octet-stream",
+				"Upload-Offset":   tt.offset,
+				"Upload-Checksum": tt.checksum,
+			})
+			if w.Code != tt.status {
+				t.Fatalf("PATCH status = %d, want %d (body %q)", w.Code, tt.status, w.Body.String())
+			}
+			if got := w.Header().Get("Upload-Offset"); tt.want != "" && got != tt.want {
+				t.Errorf("Upload-Offset = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"chat-backend-general/internal/usecases"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHandler exposes the version history of documents.
+type VersionHandler struct {
+	fileVersionUseCase usecases.FileVersionUseCase
+}
+
+func NewVersionHandler(fileVersionUseCase usecases.FileVersionUseCase) *VersionHandler {
+	return &VersionHandler{fileVersionUseCase: fileVersionUseCase}
+}
+
+// ListVersions returns the versions of the document a file ID belongs to, newest first.
+func (v *VersionHandler) ListVersions(c *gin.Context) {
+	versions, err := v.fileVersionUseCase.ListVersions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to list versions")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// RestoreVersion makes the content of a file ID the newest version of its document.
+func (v *VersionHandler) RestoreVersion(c *gin.Context) {
+	result, err := v.fileVersionUseCase.RestoreVersion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(fileErrorStatus(err), gin.H{"error": fileErrorMessage(err, "Failed to restore version")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Version restored successfully", "fileId": result.FileID, "path": result.Path,
+		"checksum": result.Checksum, "version": result.Version})
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/adaptors/repository"
+	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/usecases"
+)
+
+func TestVersionHandler_ListAndRestore(t *testing.T) {
+	r, repo := newTestRouter(t)
+	metadata := repository.NewMemoryFileMetadataRepository()
+	quota := usecases.NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil)
+	upload := usecases.NewFileUploadUseCase(repo, metadata, quota, usecases.NewFileScanUseCase(repo, nil, ""), usecases.NewFileIngestUseCase(metadata, nil, "", ""))
+	handler := NewVersionHandler(usecases.NewFileVersionUseCase(repo, metadata, quota))
+	r.GET("/doc/files/:id/versions", handler.ListVersions)
+	r.POST("/doc/files/:id/restore", handler.RestoreVersion)
+
+	var first domain.UploadResult
+	for i, content := range []string{"first", "second"} {
+		result, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+			Name: "notes.txt", File: strings.NewReader(content), Owner: "alice", ChatID: "chat-1",
+		})
+		if err != nil {
+			t.Fatalf("HandleFileUpload(%s) error = %v", content, err)
+		}
+		if i == 0 {
+			first = result
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/files/"+first.FileID+"/restore", nil))
+	var restored struct {
+		FileID  string `json:"fileId"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &restored); err != nil || w.Code != http.StatusOK || restored.Version != 3 {
+		t.Fatalf("restore = %d %q, want %d with version 3", w.Code, w.Body.String(), http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/doc/files/"+restored.FileID+"/versions", nil))
+	var listed struct {
+		Versions []domain.FileRecord `json:"versions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || w.Code != http.StatusOK || len(listed.Versions) != 3 {
+		t.Fatalf("list versions = %d %q, want %d with 3 versions", w.Code, w.Body.String(), http.StatusOK)
+	}
+	if listed.Versions[0].ID != restored.FileID || listed.Versions[0].BlobPath != first.Path {
+		t.Errorf("newest version = %+v, want %s sharing the content of %s", listed.Versions[0], restored.FileID, first.Path)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/doc/files/00000000-0000-0000-0000-000000000000/restore", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("restore unknown file status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
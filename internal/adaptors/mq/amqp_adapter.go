@@ -0,0 +1,292 @@
+package mq
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// AmqpAdapter publishes Celery tasks to an AMQP 0-9-1 broker such as RabbitMQ. Messages
+// are persistent and published with confirms, so PublishMessage returns only once the
+// broker has taken responsibility for the message. The connection is opened on first use
+// and reopened, along with the channel, after the broker or the network closed it.
+type AmqpAdapter struct {
+	url      string
+	exchange string
+	timeout  time.Duration
+	origin   string
+	logger   *zap.Logger
+
+	dial    func() (amqpConnection, error) // Opens a connection to the broker
+	mu      sync.Mutex                     // Serializes publishing, so that a return is matched with its message
+	conn    amqpConnection
+	channel amqpChannel
+	returns <-chan amqp.Return
+}
+
+// amqpConnection is the part of an AMQP connection AmqpAdapter uses.
+type amqpConnection interface {
+	// confirmChannel opens a channel in confirm mode, along with the channel its
+	// unroutable messages are returned on.
+	confirmChannel() (amqpChannel, <-chan amqp.Return, error)
+	IsClosed() bool
+	Close() error
+}
+
+// amqpChannel is the part of an AMQP channel AmqpAdapter uses.
+type amqpChannel interface {
+	// publishMandatory publishes publishing, to be returned when no queue is bound to key.
+	publishMandatory(ctx context.Context, exchange, key string, publishing amqp.Publishing) (amqpConfirmation, error)
+	IsClosed() bool
+	Close() error
+}
+
+// amqpConfirmation is the pending confirm of a published message.
+type amqpConfirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// brokerConnection is an amqpConnection to a broker.
+type brokerConnection struct {
+	*amqp.Connection
+}
+
+func (c brokerConnection) confirmChannel() (amqpChannel, <-chan amqp.Return, error) {
+	channel, err := c.Channel()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := channel.Confirm(false); err != nil {
+		_ = channel.Close()
+		return nil, nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	return brokerChannel{channel}, channel.NotifyReturn(make(chan amqp.Return, 1)), nil
+}
+
+// brokerChannel is an amqpChannel of a brokerConnection.
+type brokerChannel struct {
+	*amqp.Channel
+}
+
+func (c brokerChannel) publishMandatory(ctx context.Context, exchange, key string, publishing amqp.Publishing) (amqpConfirmation, error) {
+	confirmation, err := c.PublishWithDeferredConfirmWithContext(ctx, exchange, key, true, false, publishing)
+	if err != nil {
+		return nil, err
+	}
+	return confirmation, nil
+}
+
+// NewAmqpAdapter initializes a new AmqpAdapter publishing through exchange, with the queue
+// name as routing key. The empty exchange is the broker's default exchange, which routes
+// to the queue of that name. timeout bounds connecting and waiting for a confirm.
+func NewAmqpAdapter(url, exchange string, timeout time.Duration, logger *zap.Logger) (*AmqpAdapter, error) {
+	if _, err := amqp.ParseURI(url); err != nil {
+		logger.Error("Invalid AMQP URL", zap.Error(err))
+		return nil, fmt.Errorf("invalid AMQP URL: %w", err)
+	}
+
+	a := &AmqpAdapter{
+		url:      url,
+		exchange: exchange,
+		timeout:  timeout,
+		origin:   celeryOrigin(),
+		logger:   logger,
+	}
+	a.dial = a.dialBroker
+	return a, nil
+}
+
+// dialBroker connects to the broker at a.url.
+func (a *AmqpAdapter) dialBroker() (amqpConnection, error) {
+	conn, err := amqp.DialConfig(a.url, amqp.Config{
+		Dial:       amqp.DefaultDial(a.timeout),
+		Properties: amqp.Table{"connection_name": a.origin},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return brokerConnection{conn}, nil
+}
+
+// PublishMessage publishes a message for the specified queue, encoded as Celery's
+// RabbitMQ transport does. A message the exchange cannot route to any queue is an error.
+// A closed channel or connection is reopened and the message published once more.
+// RabbitMQ has no native delivery delay: a message with an ETA is delivered at once and
+// the worker holds the task back, but a message with an expiry is dropped once it expires.
+func (a *AmqpAdapter) PublishMessage(queueName string, message domain.CeleryMessage) error {
+	task, err := newCeleryTask(message, a.origin)
+	if err != nil {
+		a.logger.Error("Failed to encode message", zap.Error(err))
+		return err
+	}
+	ttl, err := timeToLive(message, time.Now())
+	if err != nil {
+		return err
+	}
+	publishing := newAmqpPublishing(task, ttl)
+	if priority, ok := messagePriority(message); ok {
+		// RabbitMQ delivers higher priorities first, on queues declared with x-max-priority.
+		publishing.Priority = uint8(priority)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err = a.publish(queueName, publishing)
+	if errors.Is(err, amqp.ErrClosed) {
+		a.logger.Warn("AMQP channel closed, reconnecting", zap.String("queueName", queueName))
+		err = a.publish(queueName, publishing)
+	}
+	if err != nil {
+		a.logger.Error("Failed to send message", zap.Error(err), zap.String("queueName", queueName))
+		return err
+	}
+
+	a.logger.Info("Message sent successfully", zap.String("queueName", queueName))
+	return nil
+}
+
+// publish sends publishing and waits for the broker to confirm it. a.mu must be held.
+func (a *AmqpAdapter) publish(queueName string, publishing amqp.Publishing) error {
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	confirmation, err := a.channel.publishMandatory(ctx, a.exchange, queueName, publishing)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		// The channel is in an unknown state: the confirm may still arrive. Start over
+		// with a new one.
+		a.closeChannel()
+		return fmt.Errorf("failed to confirm message: %w", err)
+	}
+	if !acked {
+		return errors.New("message rejected by the broker")
+	}
+
+	// The broker returns an unroutable message before confirming it.
+	select {
+	case returned := <-a.returns:
+		return fmt.Errorf("message not routed to a queue: %d %s", returned.ReplyCode, returned.ReplyText)
+	default:
+		return nil
+	}
+}
+
+// connect opens the connection and a channel in confirm mode unless they are open. a.mu
+// must be held.
+func (a *AmqpAdapter) connect() error {
+	if a.conn == nil || a.conn.IsClosed() {
+		a.closeChannel()
+		conn, err := a.dial()
+		if err != nil {
+			return fmt.Errorf("failed to connect to AMQP broker: %w", err)
+		}
+		a.conn = conn
+	}
+	if a.channel != nil && !a.channel.IsClosed() {
+		return nil
+	}
+
+	channel, returns, err := a.conn.confirmChannel()
+	if err != nil {
+		return fmt.Errorf("failed to open AMQP channel: %w", err)
+	}
+	a.channel, a.returns = channel, returns
+	return nil
+}
+
+// closeChannel discards the channel, if any. a.mu must be held.
+func (a *AmqpAdapter) closeChannel() {
+	if a.channel != nil {
+		_ = a.channel.Close()
+		a.channel, a.returns = nil, nil
+	}
+}
+
+// Close closes the connection to the broker.
+func (a *AmqpAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closeChannel()
+	if a.conn == nil {
+		return nil
+	}
+	err := a.conn.Close()
+	a.conn = nil
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// newAmqpPublishing encodes task as kombu's AMQP transport does: the task headers are
+// AMQP headers and the body is the JSON encoded arguments. The broker drops the message
+// after ttl, unless ttl is 0.
+func newAmqpPublishing(task celeryTask, ttl time.Duration) amqp.Publishing {
+	publishing := amqp.Publishing{
+		Headers:         amqpHeaders(task.Headers),
+		ContentType:     celeryContentType,
+		ContentEncoding: celeryContentEncoding,
+		DeliveryMode:    amqp.Persistent,
+		CorrelationId:   task.Headers.ID,
+		Body:            task.Body,
+	}
+	if ttl > 0 {
+		publishing.Expiration = strconv.FormatInt(max(ttl.Milliseconds(), 1), 10)
+	}
+	return publishing
+}
+
+// amqpHeaders converts the task headers to an AMQP table, with void fields for the
+// headers that are not set.
+func amqpHeaders(headers celeryHeaders) amqp.Table {
+	groupIndex := interface{}(nil)
+	if headers.GroupIndex != nil {
+		groupIndex = *headers.GroupIndex
+	}
+	timeLimit := make([]interface{}, len(headers.TimeLimit))
+	for i, limit := range headers.TimeLimit {
+		if limit != nil {
+			timeLimit[i] = *limit
+		}
+	}
+	return amqp.Table{
+		"lang":          headers.Lang,
+		"task":          headers.Task,
+		"id":            headers.ID,
+		"shadow":        optionalString(headers.Shadow),
+		"eta":           optionalString(headers.ETA),
+		"expires":       optionalString(headers.Expires),
+		"group":         optionalString(headers.Group),
+		"group_index":   groupIndex,
+		"retries":       headers.Retries,
+		"timelimit":     timeLimit,
+		"root_id":       headers.RootID,
+		"parent_id":     optionalString(headers.ParentID),
+		"argsrepr":      headers.ArgsRepr,
+		"kwargsrepr":    headers.KwargsRepr,
+		"origin":        headers.Origin,
+		"ignore_result": headers.IgnoreResult,
+	}
+}
+
+// optionalString returns *s, or nil when s is nil.
+func optionalString(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
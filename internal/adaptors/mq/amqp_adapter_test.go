@@ -0,0 +1,240 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+func TestNewAmqpPublishing_MatchesGolden(t *testing.T) {
+	for _, tt := range goldenTasks {
+		t.Run(tt.golden, func(t *testing.T) {
+			task, err := newCeleryTask(tt.message, "gen42@worker-host")
+			if err != nil {
+				t.Fatalf("newCeleryTask() error = %v", err)
+			}
+			publishing := newAmqpPublishing(task, 0)
+			if err := publishing.Headers.Validate(); err != nil {
+				t.Fatalf("headers are not a valid AMQP table: %v", err)
+			}
+			if publishing.DeliveryMode != amqp.Persistent || publishing.ContentType != "application/json" ||
+				publishing.ContentEncoding != "utf-8" || publishing.CorrelationId != tt.message.ID || publishing.Expiration != "" {
+				t.Errorf("publishing properties = %+v, want persistent JSON correlated with %s", publishing, tt.message.ID)
+			}
+
+			golden := readGoldenEnvelope(t, tt.golden)
+			var headers, body interface{}
+			encoded, _ := json.Marshal(publishing.Headers)
+			if err := json.Unmarshal(encoded, &headers); err != nil {
+				t.Fatalf("decoding headers: %v", err)
+			}
+			if !reflect.DeepEqual(headers, golden["headers"]) {
+				t.Errorf("headers = %v, want %v", headers, golden["headers"])
+			}
+			if err := json.Unmarshal(publishing.Body, &body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if !reflect.DeepEqual(body, golden["body"]) {
+				t.Errorf("body = %s, want %v", publishing.Body, golden["body"])
+			}
+		})
+	}
+}
+
+func TestNewAmqpPublishing_Expiration(t *testing.T) {
+	tests := []struct {
+		ttl  time.Duration
+		want string
+	}{
+		{ttl: 0, want: ""},
+		{ttl: 90 * time.Second, want: "90000"},
+		{ttl: time.Microsecond, want: "1"},
+	}
+
+	for _, tt := range tests {
+		if got := newAmqpPublishing(celeryTask{}, tt.ttl).Expiration; got != tt.want {
+			t.Errorf("newAmqpPublishing(ttl %v).Expiration = %q, want %q", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestNewAmqpAdapter_InvalidURL(t *testing.T) {
+	if _, err := NewAmqpAdapter("http://localhost:5672", "", time.Second, zap.NewNop()); err == nil {
+		t.Error("NewAmqpAdapter(http URL) error = nil, want error")
+	}
+}
+
+func TestAmqpAdapter_PublishWithoutBroker(t *testing.T) {
+	// Reserve a local port nothing listens on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	adapter, err := NewAmqpAdapter("amqp://guest:guest@"+address+"/", "", time.Second, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewAmqpAdapter() error = %v", err)
+	}
+	defer adapter.Close()
+	message := domain.NewCeleryMessage("tasks.add", []interface{}{2, 3}, nil)
+	if err := adapter.PublishMessage("celery", message); err == nil {
+		t.Error("PublishMessage() without broker error = nil, want error")
+	}
+}
+
+// fakeAmqpConnection is an amqpConnection whose channels publish with fakeAmqpChannel.
+type fakeAmqpConnection struct {
+	closed   bool
+	channels []*fakeAmqpChannel // Channels opened so far, the last one in use
+	next     fakeAmqpChannel    // Behaviour of the channels opened next
+}
+
+func (c *fakeAmqpConnection) confirmChannel() (amqpChannel, <-chan amqp.Return, error) {
+	channel := c.next
+	channel.conn = c
+	channel.returns = make(chan amqp.Return, 1)
+	c.channels = append(c.channels, &channel)
+	return &channel, channel.returns, nil
+}
+
+func (c *fakeAmqpConnection) IsClosed() bool { return c.closed }
+func (c *fakeAmqpConnection) Close() error   { c.closed = true; return nil }
+
+// fakeAmqpChannel confirms what it publishes with acked, unless waitErr is set, and
+// returns it first when unroutable is set. A closed channel fails with amqp.ErrClosed.
+type fakeAmqpChannel struct {
+	conn       *fakeAmqpConnection
+	closed     bool
+	closing    bool // Closed by the broker on the next publish
+	dropping   bool // Closed along with its connection on the next publish
+	acked      bool
+	waitErr    error
+	unroutable bool
+	returns    chan amqp.Return
+	published  []amqp.Publishing
+}
+
+func (c *fakeAmqpChannel) publishMandatory(ctx context.Context, exchange, key string, publishing amqp.Publishing) (amqpConfirmation, error) {
+	if c.closing || c.dropping {
+		c.closed, c.conn.closed = true, c.dropping
+	}
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	c.published = append(c.published, publishing)
+	if c.unroutable {
+		c.returns <- amqp.Return{ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE", RoutingKey: key}
+	}
+	return fakeAmqpConfirmation{acked: c.acked, err: c.waitErr}, nil
+}
+
+func (c *fakeAmqpChannel) IsClosed() bool { return c.closed }
+func (c *fakeAmqpChannel) Close() error   { c.closed = true; return nil }
+
+type fakeAmqpConfirmation struct {
+	acked bool
+	err   error
+}
+
+func (c fakeAmqpConfirmation) WaitContext(ctx context.Context) (bool, error) {
+	return c.acked, c.err
+}
+
+// newFakeAmqpAdapter returns an AmqpAdapter whose connections are conns, in order.
+func newFakeAmqpAdapter(t *testing.T, conns ...*fakeAmqpConnection) *AmqpAdapter {
+	adapter, err := NewAmqpAdapter("amqp://localhost/", "", time.Second, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewAmqpAdapter() error = %v", err)
+	}
+	adapter.dial = func() (amqpConnection, error) {
+		if len(conns) == 0 {
+			return nil, errors.New("connection refused")
+		}
+		conn := conns[0]
+		conns = conns[1:]
+		return conn, nil
+	}
+	return adapter
+}
+
+func TestAmqpAdapter_PublishConfirms(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel fakeAmqpChannel
+		err     string
+	}{
+		{name: "acked", channel: fakeAmqpChannel{acked: true}},
+		{name: "nacked", channel: fakeAmqpChannel{acked: false}, err: "rejected by the broker"},
+		{name: "unroutable", channel: fakeAmqpChannel{acked: true, unroutable: true}, err: "not routed to a queue: 312 NO_ROUTE"},
+		{name: "unconfirmed", channel: fakeAmqpChannel{waitErr: context.DeadlineExceeded}, err: "failed to confirm message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeAmqpConnection{next: tt.channel}
+			adapter := newFakeAmqpAdapter(t, conn)
+
+			err := adapter.PublishMessage("celery", domain.NewCeleryMessage("tasks.add", []interface{}{2, 3}, nil))
+			if (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("PublishMessage() error = %v, want %q", err, tt.err)
+			}
+			if len(conn.channels) != 1 || len(conn.channels[0].published) != 1 {
+				t.Fatalf("channels = %+v, want one message published on one channel", conn.channels)
+			}
+			// A channel left waiting for a confirm is discarded.
+			if closed := conn.channels[0].closed; closed != (tt.channel.waitErr != nil) {
+				t.Errorf("channel closed = %v, want %v", closed, tt.channel.waitErr != nil)
+			}
+		})
+	}
+}
+
+func TestAmqpAdapter_ReopensAfterClose(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(channel *fakeAmqpChannel)
+		dials int
+	}{
+		{name: "channel", close: func(channel *fakeAmqpChannel) { channel.closing = true }, dials: 1},
+		{name: "connection", close: func(channel *fakeAmqpChannel) { channel.dropping = true }, dials: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := &fakeAmqpConnection{next: fakeAmqpChannel{acked: true}}
+			second := &fakeAmqpConnection{next: fakeAmqpChannel{acked: true}}
+			adapter := newFakeAmqpAdapter(t, first, second)
+			message := domain.NewCeleryMessage("tasks.add", []interface{}{2, 3}, nil)
+			if err := adapter.PublishMessage("celery", message); err != nil {
+				t.Fatalf("PublishMessage() error = %v", err)
+			}
+
+			// The broker closes the channel, or the connection is lost, before the next
+			// message: publishing it fails with amqp.ErrClosed and is retried once.
+			tt.close(first.channels[0])
+			if err := adapter.PublishMessage("celery", message); err != nil {
+				t.Fatalf("PublishMessage() after close error = %v", err)
+			}
+
+			channels := append(first.channels[1:], second.channels...)
+			if dials := 1 + len(second.channels); dials != tt.dials {
+				t.Errorf("connections dialed = %d, want %d", dials, tt.dials)
+			}
+			if len(channels) != 1 || len(channels[0].published) != 1 {
+				t.Errorf("reopened channels = %+v, want the message published on one", channels)
+			}
+		})
+	}
+}
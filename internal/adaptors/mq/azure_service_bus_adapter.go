@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
@@ -15,6 +17,7 @@ import (
 // AzureServiceBusAdapter is an adapter for Azure Service Bus.
 type AzureServiceBusAdapter struct {
 	client *azservicebus.Client
+	origin string
 	logger *zap.Logger
 }
 
@@ -28,42 +31,118 @@ func NewAzureServiceBusAdapter(connectionString string, logger *zap.Logger) (*Az
 
 	return &AzureServiceBusAdapter{
 		client: client,
+		origin: celeryOrigin(),
 		logger: logger,
 	}, nil
 }
 
-// PublishMessage publishes a message to the specified Azure Service Bus queue.
+// PublishMessage publishes a message to the specified Azure Service Bus queue. The message
+// is encoded as kombu's Azure Service Bus transport does, so that Celery workers consuming
+// the queue accept it. A message with a future ETA is scheduled to be enqueued at its ETA,
+// and a message with an expiry is removed from the queue once it expires.
 func (a *AzureServiceBusAdapter) PublishMessage(queueName string, message domain.CeleryMessage) error {
+	_, err := a.ScheduleMessage(queueName, message)
+	return err
+}
+
+// ScheduleMessage publishes message like PublishMessage. When its ETA is in the future,
+// the returned schedule ID is the sequence number Service Bus assigned to the scheduled
+// message, by which any instance can cancel it.
+func (a *AzureServiceBusAdapter) ScheduleMessage(queueName string, message domain.CeleryMessage) (string, error) {
 	if a.client == nil {
 		a.logger.Error("Service Bus client is nil")
-		return errors.New("service bus client is nil")
+		return "", errors.New("service bus client is nil")
 	}
 
 	sender, err := a.client.NewSender(queueName, nil)
 	if err != nil {
 		a.logger.Error("Failed to create sender", zap.Error(err), zap.String("queueName", queueName))
-		return fmt.Errorf("failed to create sender: %w", err)
+		return "", fmt.Errorf("failed to create sender: %w", err)
 	}
 	defer sender.Close(context.Background())
 
-	messageBytes, err := json.Marshal(message)
+	task, err := newCeleryTask(message, a.origin)
+	if err != nil {
+		a.logger.Error("Failed to encode message", zap.Error(err))
+		return "", err
+	}
+	messageBytes, err := json.Marshal(task.envelope(queueName, 0))
 	if err != nil {
 		a.logger.Error("Failed to marshal message", zap.Error(err))
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return "", fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	ttl := time.Hour * 1
+	enqueueAt := time.Now()
+	scheduled := message.ETA != nil && message.ETA.After(enqueueAt)
+	if scheduled {
+		enqueueAt = *message.ETA
+	}
+	ttl, err := timeToLive(message, enqueueAt)
+	if err != nil {
+		return "", err
+	}
 
+	contentType := celeryContentType
 	sbMessage := &azservicebus.Message{
-		Body:       messageBytes,
-		TimeToLive: &ttl, // Message TTL
+		MessageID:   &message.ID,
+		Body:        messageBytes,
+		ContentType: &contentType,
+	}
+	if ttl > 0 {
+		sbMessage.TimeToLive = &ttl
+	}
+
+	if !scheduled {
+		if err := sender.SendMessage(context.Background(), sbMessage, nil); err != nil {
+			a.logger.Error("Failed to send message", zap.Error(err), zap.String("queueName", queueName))
+			return "", fmt.Errorf("failed to send message: %w", err)
+		}
+		a.logger.Info("Message sent successfully", zap.String("queueName", queueName))
+		return "", nil
+	}
+
+	sequenceNumbers, err := sender.ScheduleMessages(context.Background(), []*azservicebus.Message{sbMessage}, enqueueAt, nil)
+	if err != nil {
+		a.logger.Error("Failed to schedule message", zap.Error(err), zap.String("queueName", queueName))
+		return "", fmt.Errorf("failed to schedule message: %w", err)
 	}
+	scheduleID := strconv.FormatInt(sequenceNumbers[0], 10)
 
-	if err := sender.SendMessage(context.Background(), sbMessage, nil); err != nil {
-		a.logger.Error("Failed to send message", zap.Error(err), zap.String("queueName", queueName))
-		return fmt.Errorf("failed to send message: %w", err)
+	a.logger.Info("Message scheduled successfully", zap.String("queueName", queueName), zap.Time("enqueueAt", enqueueAt), zap.String("scheduleID", scheduleID))
+	return scheduleID, nil
+}
+
+// CancelScheduledMessage cancels the message scheduled on queueName with the sequence
+// number scheduleID. Service Bus answers 404 for messages that are unknown or already
+// enqueued, reported as domain.ErrScheduledMessageNotFound.
+func (a *AzureServiceBusAdapter) CancelScheduledMessage(queueName, scheduleID string) error {
+	sequenceNumber, err := strconv.ParseInt(scheduleID, 10, 64)
+	if err != nil {
+		return domain.ErrScheduledMessageNotFound
+	}
+
+	sender, err := a.client.NewSender(queueName, nil)
+	if err != nil {
+		a.logger.Error("Failed to create sender", zap.Error(err), zap.String("queueName", queueName))
+		return fmt.Errorf("failed to create sender: %w", err)
 	}
+	defer sender.Close(context.Background())
 
-	a.logger.Info("Message sent successfully", zap.String("queueName", queueName))
+	if err := sender.CancelScheduledMessages(context.Background(), []int64{sequenceNumber}, nil); err != nil {
+		if isRPCNotFound(err) {
+			return domain.ErrScheduledMessageNotFound
+		}
+		a.logger.Error("Failed to cancel scheduled message", zap.Error(err), zap.String("scheduleID", scheduleID))
+		return fmt.Errorf("failed to cancel scheduled message: %w", err)
+	}
+
+	a.logger.Info("Scheduled message cancelled", zap.String("queueName", queueName), zap.String("scheduleID", scheduleID))
 	return nil
 }
+
+// isRPCNotFound reports whether err is a 404 answer to a Service Bus management request.
+// The SDK does not export its error type, only the RPCCode method on it.
+func isRPCNotFound(err error) bool {
+	var rpcErr interface{ RPCCode() int }
+	return errors.As(err, &rpcErr) && rpcErr.RPCCode() == http.StatusNotFound
+}
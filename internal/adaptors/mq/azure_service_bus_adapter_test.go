@@ -0,0 +1,36 @@
+package mq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// rpcError mimics the error the Service Bus SDK returns for failed management requests.
+type rpcError struct {
+	code int
+}
+
+func (e rpcError) Error() string { return fmt.Sprintf("rpc: failed, status code %d", e.code) }
+func (e rpcError) RPCCode() int  { return e.code }
+
+func TestIsRPCNotFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "not found", err: rpcError{code: 404}, expected: true},
+		{name: "wrapped not found", err: fmt.Errorf("cancel: %w", rpcError{code: 404}), expected: true},
+		{name: "other status", err: rpcError{code: 500}, expected: false},
+		{name: "other error", err: errors.New("connection lost"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRPCNotFound(tt.err); got != tt.expected {
+				t.Errorf("isRPCNotFound(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package mq
+
+import (
+	"chat-backend-general/internal/domain"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Content properties of the task messages: the body is JSON, as with Celery's default
+// json serializer.
+const (
+	celeryContentType     = "application/json"
+	celeryContentEncoding = "utf-8"
+)
+
+// celeryTask is a task message in the Celery message protocol v2: the headers describe
+// the task and the body holds its arguments. Brokers with native headers, such as AMQP,
+// carry them as message headers; the others wrap them in a celeryEnvelope.
+type celeryTask struct {
+	Headers celeryHeaders
+	Body    []byte
+}
+
+// celeryHeaders are the protocol v2 headers of a task message. Optional headers are
+// sent as null, as Celery does.
+type celeryHeaders struct {
+	Lang         string      `json:"lang"`
+	Task         string      `json:"task"`
+	ID           string      `json:"id"`
+	Shadow       *string     `json:"shadow"`
+	ETA          *string     `json:"eta"`
+	Expires      *string     `json:"expires"`
+	Group        *string     `json:"group"`
+	GroupIndex   *int        `json:"group_index"`
+	Retries      int         `json:"retries"`
+	TimeLimit    [2]*float64 `json:"timelimit"`
+	RootID       string      `json:"root_id"`
+	ParentID     *string     `json:"parent_id"`
+	ArgsRepr     string      `json:"argsrepr"`
+	KwargsRepr   string      `json:"kwargsrepr"`
+	Origin       string      `json:"origin"`
+	IgnoreResult bool        `json:"ignore_result"`
+}
+
+// celeryEmbed is the third element of the body, holding the workflow of the task. This
+// service sends no workflows.
+type celeryEmbed struct {
+	Callbacks []interface{} `json:"callbacks"`
+	Errbacks  []interface{} `json:"errbacks"`
+	Chain     []interface{} `json:"chain"`
+	Chord     interface{}   `json:"chord"`
+}
+
+// celeryEnvelope is a task message as kombu's virtual transports (Azure Service Bus,
+// Redis, SQS, ...) store it: a JSON document with the body base64 encoded.
+type celeryEnvelope struct {
+	Body            string           `json:"body"`
+	ContentEncoding string           `json:"content-encoding"`
+	ContentType     string           `json:"content-type"`
+	Headers         celeryHeaders    `json:"headers"`
+	Properties      celeryProperties `json:"properties"`
+}
+
+// celeryProperties are the message properties of a celeryEnvelope.
+type celeryProperties struct {
+	CorrelationID string             `json:"correlation_id"`
+	ReplyTo       string             `json:"reply_to"`
+	DeliveryMode  int                `json:"delivery_mode"`
+	DeliveryInfo  celeryDeliveryInfo `json:"delivery_info"`
+	Priority      int                `json:"priority"`
+	BodyEncoding  string             `json:"body_encoding"`
+	DeliveryTag   string             `json:"delivery_tag"`
+}
+
+// celeryDeliveryInfo tells the worker where a message was routed.
+type celeryDeliveryInfo struct {
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routing_key"`
+}
+
+// newCeleryTask encodes message as a protocol v2 task sent by origin.
+func newCeleryTask(message domain.CeleryMessage, origin string) (celeryTask, error) {
+	args := message.Args
+	if args == nil {
+		args = []interface{}{}
+	}
+	kwargs := message.Kwargs
+	if kwargs == nil {
+		kwargs = map[string]interface{}{}
+	}
+	body, err := json.Marshal([]interface{}{args, kwargs, celeryEmbed{}})
+	if err != nil {
+		return celeryTask{}, fmt.Errorf("failed to encode the arguments of task %s: %w", message.ID, err)
+	}
+
+	rootID := message.RootID
+	if rootID == "" {
+		rootID = message.ID
+	}
+	headers := celeryHeaders{
+		Lang:       "py",
+		Task:       message.Task,
+		ID:         message.ID,
+		ETA:        celeryTime(message.ETA),
+		Expires:    celeryTime(message.Expires),
+		Retries:    message.Retries,
+		RootID:     rootID,
+		ArgsRepr:   pythonTupleRepr(args),
+		KwargsRepr: pythonRepr(kwargs),
+		Origin:     origin,
+	}
+	if message.ParentID != "" {
+		headers.ParentID = &message.ParentID
+	}
+	return celeryTask{Headers: headers, Body: body}, nil
+}
+
+// envelope wraps the task for a virtual transport, routed to routingKey through the
+// default exchange, with priority on the transport's scale.
+func (t celeryTask) envelope(routingKey string, priority int) celeryEnvelope {
+	return celeryEnvelope{
+		Body:            base64.StdEncoding.EncodeToString(t.Body),
+		ContentEncoding: celeryContentEncoding,
+		ContentType:     celeryContentType,
+		Headers:         t.Headers,
+		Properties: celeryProperties{
+			CorrelationID: t.Headers.ID,
+			DeliveryMode:  2, // Persistent
+			DeliveryInfo:  celeryDeliveryInfo{RoutingKey: routingKey},
+			Priority:      priority,
+			BodyEncoding:  "base64",
+			DeliveryTag:   uuid.New().String(),
+		},
+	}
+}
+
+// messagePriority returns the priority of message clamped to 0-domain.MaxCeleryPriority,
+// and whether it has one.
+func messagePriority(message domain.CeleryMessage) (int, bool) {
+	if message.Priority == nil {
+		return 0, false
+	}
+	return min(max(*message.Priority, 0), domain.MaxCeleryPriority), true
+}
+
+// timeToLive returns how long a message enqueued at enqueuedAt stays deliverable: until it
+// expires, or without limit, 0, when it has no expiry.
+func timeToLive(message domain.CeleryMessage, enqueuedAt time.Time) (time.Duration, error) {
+	if message.Expires == nil {
+		return 0, nil
+	}
+	ttl := message.Expires.Sub(enqueuedAt)
+	if ttl <= 0 {
+		return 0, domain.ErrMessageExpired
+	}
+	return ttl, nil
+}
+
+// celeryOrigin returns the name Celery gives to clients that are not workers, gen<pid>@<host>.
+func celeryOrigin() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return fmt.Sprintf("gen%d@%s", os.Getpid(), host)
+}
+
+// celeryTime formats t like Python's datetime.isoformat, or returns nil when t is nil.
+func celeryTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	layout := "2006-01-02T15:04:05-07:00"
+	if t.Nanosecond()/int(time.Microsecond) != 0 {
+		layout = "2006-01-02T15:04:05.000000-07:00"
+	}
+	formatted := t.Format(layout)
+	return &formatted
+}
+
+// pythonTupleRepr returns the Python repr of args as a tuple, the argsrepr header.
+func pythonTupleRepr(args []interface{}) string {
+	if len(args) == 1 {
+		return "(" + pythonRepr(args[0]) + ",)"
+	}
+	items := make([]string, len(args))
+	for i, arg := range args {
+		items[i] = pythonRepr(arg)
+	}
+	return "(" + strings.Join(items, ", ") + ")"
+}
+
+// pythonRepr returns the Python repr of value as a worker decodes it from JSON. Dict keys
+// are sorted, as they are in the JSON body.
+func pythonRepr(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case string:
+		return pythonStringRepr(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = pythonRepr(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = pythonStringRepr(key) + ": " + pythonRepr(v[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		// Other types reach the worker as their JSON encoding.
+		var decoded interface{}
+		encoded, err := json.Marshal(v)
+		if err != nil || json.Unmarshal(encoded, &decoded) != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return pythonRepr(decoded)
+	}
+}
+
+// pythonStringRepr quotes s like Python's repr: single quotes unless s contains single
+// quotes and no double quotes.
+func pythonStringRepr(s string) string {
+	quote := '\''
+	if strings.ContainsRune(s, '\'') && !strings.ContainsRune(s, '"') {
+		quote = '"'
+	}
+	var b strings.Builder
+	b.WriteRune(quote)
+	for _, r := range s {
+		switch {
+		case r == quote || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune(quote)
+	return b.String()
+}
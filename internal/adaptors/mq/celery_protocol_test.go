@@ -0,0 +1,194 @@
+package mq
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+var (
+	goldenETA     = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	goldenExpires = time.Date(2024, 5, 1, 13, 30, 0, 250_000_000, time.UTC)
+)
+
+// goldenTasks are the tasks of the golden files in testdata, which hold the envelopes
+// Celery 5 sends for the same tasks, with the body serialized by Python's json module.
+var goldenTasks = []struct {
+	golden  string
+	queue   string
+	message domain.CeleryMessage
+}{
+	{
+		golden: "positional_args",
+		queue:  "celery",
+		message: domain.CeleryMessage{
+			Task: "tasks.add", ID: "c8e1a0f2-5b1f-4d7e-9a43-2f6a1d9b7e01", Args: []interface{}{2, 3},
+		},
+	},
+	{
+		golden: "ingest_document",
+		queue:  "ingest",
+		message: domain.CeleryMessage{
+			Task: "tasks.ingest_document", ID: "0b7e4f3a-8c2d-4e19-b6a5-93d1c2e4f507", Args: []interface{}{},
+			Kwargs: map[string]interface{}{
+				"file_id": "file-1", "path": "alice/chat-1/file-1_O'Brien's notes.pdf",
+				"blob_path": "alice/chat-0/file-0_O'Brien's notes.pdf", "content_type": "application/pdf",
+				"owner": "alice", "chat_id": "chat-1",
+			},
+			RootID: "5d2c1b0a-7e6f-4a3b-9c8d-1e2f3a4b5c6d", ParentID: "9a8b7c6d-5e4f-4321-8fed-cba987654321",
+			Retries: 2, ETA: &goldenETA, Expires: &goldenExpires,
+		},
+	},
+	{
+		golden: "mixed_values",
+		queue:  "celery",
+		message: domain.CeleryMessage{
+			Task: "tasks.echo", ID: "3f2e1d0c-b9a8-4765-8432-10fedcba9876",
+			Args:   []interface{}{"a\nb", 1.5, true, nil, []interface{}{1, "x"}},
+			Kwargs: map[string]interface{}{"quote": `say "hi"`, "nested": map[string]interface{}{"k": 10}},
+		},
+	},
+}
+
+func TestCeleryTask_EnvelopeMatchesGolden(t *testing.T) {
+	for _, tt := range goldenTasks {
+		t.Run(tt.golden, func(t *testing.T) {
+			task, err := newCeleryTask(tt.message, "gen42@worker-host")
+			if err != nil {
+				t.Fatalf("newCeleryTask() error = %v", err)
+			}
+			envelope := task.envelope(tt.queue, 0)
+			envelope.Properties.DeliveryTag = "7f1d2c3b-0000-4000-8000-000000000000"
+			encoded, err := json.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			if got, want := decodeEnvelope(t, encoded), readGoldenEnvelope(t, tt.golden); !reflect.DeepEqual(got, want) {
+				t.Errorf("envelope =\n%s\nwant\n%v", encoded, want)
+			}
+		})
+	}
+}
+
+// readGoldenEnvelope decodes the envelope of testdata/<name>.golden.json.
+func readGoldenEnvelope(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+	golden, err := os.ReadFile(filepath.Join("testdata", name+".golden.json"))
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	return decodeEnvelope(t, golden)
+}
+
+// decodeEnvelope decodes an envelope along with its base64 body, which Go and Python
+// serialize with different whitespace.
+func decodeEnvelope(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatalf("decoding envelope: %v", err)
+	}
+	body, err := base64.StdEncoding.DecodeString(envelope["body"].(string))
+	if err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("decoding body %s: %v", body, err)
+	}
+	envelope["body"] = decoded
+	return envelope
+}
+
+func TestPythonRepr(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{value: nil, want: "None"},
+		{value: false, want: "False"},
+		{value: 42, want: "42"},
+		{value: 42.0, want: "42"},
+		{value: 0.1, want: "0.1"},
+		{value: 1e20, want: "1e+20"},
+		{value: "it's", want: `"it's"`},
+		{value: `it's "quoted"`, want: `'it\'s "quoted"'`},
+		{value: "tab\there\x01", want: `'tab\there\x01'`},
+		{value: "naïve", want: "'naïve'"},
+		{value: []interface{}{}, want: "[]"},
+		{value: map[string]interface{}{"b": 1, "a": []interface{}{"x"}}, want: "{'a': ['x'], 'b': 1}"},
+		{value: struct {
+			Name string `json:"name"`
+		}{Name: "x"}, want: "{'name': 'x'}"},
+	}
+
+	for _, tt := range tests {
+		if got := pythonRepr(tt.value); got != tt.want {
+			t.Errorf("pythonRepr(%#v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	if got := pythonTupleRepr([]interface{}{"only"}); got != "('only',)" {
+		t.Errorf("pythonTupleRepr(one) = %s, want ('only',)", got)
+	}
+	if got := pythonTupleRepr(nil); got != "()" {
+		t.Errorf("pythonTupleRepr(nil) = %s, want ()", got)
+	}
+}
+
+func TestTimeToLive(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Second)
+
+	tests := []struct {
+		name    string
+		expires *time.Time
+		want    time.Duration
+		err     error
+	}{
+		{name: "no expiry"},
+		{name: "expires later", expires: &later, want: time.Hour},
+		{name: "expired", expires: &earlier, err: domain.ErrMessageExpired},
+		{name: "expires now", expires: &now, err: domain.ErrMessageExpired},
+	}
+
+	for _, tt := range tests {
+		ttl, err := timeToLive(domain.CeleryMessage{Expires: tt.expires}, now)
+		if ttl != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("timeToLive(%s) = %v, %v, want %v, %v", tt.name, ttl, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestMessagePriority(t *testing.T) {
+	tests := []struct {
+		priority *int
+		want     int
+		ok       bool
+	}{
+		{priority: nil, want: 0, ok: false},
+		{priority: intPointer(0), want: 0, ok: true},
+		{priority: intPointer(5), want: 5, ok: true},
+		{priority: intPointer(-1), want: 0, ok: true},
+		{priority: intPointer(200), want: domain.MaxCeleryPriority, ok: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := messagePriority(domain.CeleryMessage{Priority: tt.priority})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("messagePriority(%v) = %d, %v, want %d, %v", tt.priority, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func intPointer(i int) *int {
+	return &i
+}
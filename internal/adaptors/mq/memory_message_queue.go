@@ -0,0 +1,81 @@
+package mq
+
+import (
+	"chat-backend-general/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryMessageQueue keeps published messages in process memory, queue by queue. Nothing
+// consumes them; they can be inspected, which makes it suitable for local development
+// and tests. Messages are lost when the process exits.
+type MemoryMessageQueue struct {
+	mu     sync.Mutex
+	queues map[string][]domain.CeleryMessage
+}
+
+// NewMemoryMessageQueue creates an empty MemoryMessageQueue.
+func NewMemoryMessageQueue() *MemoryMessageQueue {
+	return &MemoryMessageQueue{queues: map[string][]domain.CeleryMessage{}}
+}
+
+// PublishMessage appends message to the specified queue.
+func (q *MemoryMessageQueue) PublishMessage(queueName string, message domain.CeleryMessage) error {
+	if _, err := timeToLive(message, time.Now()); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queues[queueName] = append(q.queues[queueName], message)
+	return nil
+}
+
+// ScheduleMessage appends message to the specified queue; its ID is its schedule ID.
+func (q *MemoryMessageQueue) ScheduleMessage(queueName string, message domain.CeleryMessage) (string, error) {
+	if err := q.PublishMessage(queueName, message); err != nil {
+		return "", err
+	}
+	return message.ID, nil
+}
+
+// CancelScheduledMessage removes the message of queueName with ID scheduleID if its ETA is still ahead.
+func (q *MemoryMessageQueue) CancelScheduledMessage(queueName, scheduleID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	messages := q.queues[queueName]
+	for i, message := range messages {
+		if message.ID == scheduleID && message.ETA != nil && message.ETA.After(now) {
+			q.queues[queueName] = append(messages[:i:i], messages[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrScheduledMessageNotFound
+}
+
+// Messages returns the messages published to queueName, oldest first.
+func (q *MemoryMessageQueue) Messages(queueName string) []domain.CeleryMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]domain.CeleryMessage(nil), q.queues[queueName]...)
+}
+
+// Queues returns the names of the queues messages were published to, sorted.
+func (q *MemoryMessageQueue) Queues() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	names := make([]string, 0, len(q.queues))
+	for name := range q.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reset removes every message.
+func (q *MemoryMessageQueue) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queues = map[string][]domain.CeleryMessage{}
+}
@@ -0,0 +1,44 @@
+package mq
+
+import (
+	"reflect"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestMemoryMessageQueue(t *testing.T) {
+	queue := NewMemoryMessageQueue()
+	first := domain.NewCeleryMessage("tasks.add", []interface{}{2, 3}, nil)
+	second := domain.NewCeleryMessage("tasks.add", []interface{}{4, 5}, nil)
+	other := domain.NewCeleryMessage("tasks.ingest_document", nil, nil)
+	for _, publish := range []struct {
+		queue   string
+		message domain.CeleryMessage
+	}{{"default", first}, {"ingest", other}, {"default", second}} {
+		if err := queue.PublishMessage(publish.queue, publish.message); err != nil {
+			t.Fatalf("PublishMessage() error = %v", err)
+		}
+	}
+
+	if got, want := queue.Queues(), []string{"default", "ingest"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Queues() = %v, want %v", got, want)
+	}
+	messages := queue.Messages("default")
+	if len(messages) != 2 || messages[0].ID != first.ID || messages[1].ID != second.ID {
+		t.Errorf("Messages(default) = %+v, want the two messages in order", messages)
+	}
+	// The returned slice is a copy.
+	messages[0] = other
+	if queue.Messages("default")[0].ID != first.ID {
+		t.Error("modifying the result of Messages() changed the queue")
+	}
+	if got := queue.Messages("missing"); len(got) != 0 {
+		t.Errorf("Messages(missing) = %+v, want none", got)
+	}
+
+	queue.Reset()
+	if got := queue.Queues(); len(got) != 0 {
+		t.Errorf("Queues() after Reset() = %v, want none", got)
+	}
+}
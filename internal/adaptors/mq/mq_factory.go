@@ -0,0 +1,42 @@
+package mq
+
+import (
+	"chat-backend-general/config"
+	"chat-backend-general/internal/domain"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Supported values for MQ_BROKER.
+const (
+	BrokerAzureServiceBus = "azure-servicebus"
+	BrokerAmqp            = "amqp"
+	BrokerRedis           = "redis"
+	BrokerMemory          = "memory"
+)
+
+// NewMessageQueue returns the message queue adapter selected by cfg.Mq.Broker, Azure
+// Service Bus when it is empty. The in-memory queue is only used when selected
+// explicitly, so that a missing broker configuration fails instead of losing messages.
+func NewMessageQueue(cfg *config.Config, logger *zap.Logger) (domain.MessageQueue, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+
+	switch strings.ToLower(cfg.Mq.Broker) {
+	case BrokerAzureServiceBus, "azure", "servicebus", "":
+		return NewAzureServiceBusAdapter(cfg.ServiceBus.ConnectionString, logger)
+	case BrokerAmqp, "rabbitmq":
+		return NewAmqpAdapter(cfg.Amqp.Url, cfg.Amqp.Exchange, cfg.Amqp.Timeout, logger)
+	case BrokerRedis:
+		return NewRedisAdapter(cfg.Redis.Url, cfg.Redis.KeyPrefix, logger)
+	case BrokerMemory:
+		logger.Warn("MQ_BROKER is memory, published messages are kept in memory and never delivered")
+		return NewMemoryMessageQueue(), nil
+	default:
+		return nil, fmt.Errorf("unsupported message broker %q", cfg.Mq.Broker)
+	}
+}
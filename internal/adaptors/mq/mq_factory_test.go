@@ -0,0 +1,45 @@
+package mq
+
+import (
+	"fmt"
+	"testing"
+
+	"chat-backend-general/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/zap"
+)
+
+func TestNewMessageQueue(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+		err  bool
+	}{
+		{name: "Azure by default", err: true},
+		{name: "memory", cfg: config.Config{Mq: config.MqConfig{Broker: "Memory"}}, want: "*mq.MemoryMessageQueue"},
+		{name: "redis", cfg: config.Config{Mq: config.MqConfig{Broker: "redis"}, Redis: config.RedisConfig{Url: "redis://" + server.Addr()}}, want: "*mq.RedisAdapter"},
+		{name: "amqp", cfg: config.Config{Mq: config.MqConfig{Broker: "amqp"}, Amqp: config.AmqpConfig{Url: "amqp://localhost:5672/"}}, want: "*mq.AmqpAdapter"},
+		{name: "azure without connection string", cfg: config.Config{Mq: config.MqConfig{Broker: "azure-servicebus"}}, err: true},
+		{name: "azure by default with connection string", cfg: config.Config{ServiceBus: config.ServiceBusConfig{ConnectionString: "invalid"}}, err: true},
+		{name: "unsupported", cfg: config.Config{Mq: config.MqConfig{Broker: "kafka"}}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue, err := NewMessageQueue(&tt.cfg, zap.NewNop())
+			if (err != nil) != tt.err {
+				t.Fatalf("NewMessageQueue() error = %v, want error %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if got := fmt.Sprintf("%T", queue); got != tt.want {
+				t.Errorf("NewMessageQueue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
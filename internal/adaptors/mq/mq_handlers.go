@@ -3,6 +3,8 @@ package mq
 import (
 	"chat-backend-general/internal/domain"
 	usecases "chat-backend-general/internal/usecases/mq"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -22,10 +24,12 @@ func NewMessageQueueHandler(useCase usecases.MessageQueueUseCase) *MessageQueueH
 func (h *MessageQueueHandler) PublishMessage(c *gin.Context) {
 	// Define the request structure
 	var request struct {
-		Task   string                 `json:"task" binding:"required"`
-		Args   []interface{}          `json:"args"`
-		Kwargs map[string]interface{} `json:"kwargs"`
-		ETA    *string                `json:"eta,omitempty"`
+		Task     string                 `json:"task" binding:"required"`
+		Args     []interface{}          `json:"args"`
+		Kwargs   map[string]interface{} `json:"kwargs"`
+		ETA      *string                `json:"eta,omitempty"`
+		Expires  *string                `json:"expires,omitempty"`
+		Priority *int                   `json:"priority"` // 0 to domain.MaxCeleryPriority
 	}
 
 	// Bind and validate the JSON request payload
@@ -37,14 +41,23 @@ func (h *MessageQueueHandler) PublishMessage(c *gin.Context) {
 		return
 	}
 
+	if request.Priority != nil && (*request.Priority < 0 || *request.Priority > domain.MaxCeleryPriority) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid priority",
+			"details": fmt.Sprintf("priority must be between 0 and %d", domain.MaxCeleryPriority),
+		})
+		return
+	}
+
 	// Extract queue name or use default
 	queueName := c.DefaultQuery("queueName", "default")
 
 	// Construct Celery-compatible message
 	message := domain.NewCeleryMessage(request.Task, request.Args, request.Kwargs)
+	message.Priority = request.Priority
 	if request.ETA != nil {
 		// Optionally set the ETA
-		parsedETA, err := parseETA(*request.ETA)
+		parsedETA, err := parseTimestamp(*request.ETA)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Invalid ETA format",
@@ -54,9 +67,29 @@ func (h *MessageQueueHandler) PublishMessage(c *gin.Context) {
 		}
 		message.ETA = &parsedETA
 	}
+	if request.Expires != nil {
+		// Optionally set the expiry, which must leave time to deliver the message
+		parsedExpires, err := parseTimestamp(*request.Expires)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid expires format",
+				"details": err.Error(),
+			})
+			return
+		}
+		if !parsedExpires.After(time.Now()) || (message.ETA != nil && !parsedExpires.After(*message.ETA)) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid expires",
+				"details": "expires must be after the current time and after the ETA",
+			})
+			return
+		}
+		message.Expires = &parsedExpires
+	}
 
 	// Publish the message
-	if err := h.useCase.Publish(queueName, message); err != nil {
+	scheduleID, err := h.useCase.Publish(queueName, message)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to publish message",
 			"details": err.Error(),
@@ -64,18 +97,48 @@ func (h *MessageQueueHandler) PublishMessage(c *gin.Context) {
 		return
 	}
 
-	// Success response
-	c.JSON(http.StatusOK, gin.H{
+	// Success response, with the ID that cancels a scheduled message
+	response := gin.H{
 		"status":    "Message published successfully",
 		"messageID": message.ID,
+	}
+	if scheduleID != "" {
+		response["scheduleID"] = scheduleID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelScheduledMessage handles cancelling a message published with an ETA before it is
+// delivered, by the schedule ID returned when it was published to the queue
+func (h *MessageQueueHandler) CancelScheduledMessage(c *gin.Context) {
+	scheduleID := c.Param("id")
+	queueName := c.DefaultQuery("queueName", "default")
+	if err := h.useCase.CancelScheduled(queueName, scheduleID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrScheduledMessageNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, domain.ErrSchedulingUnsupported):
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to cancel scheduled message",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "Scheduled message cancelled successfully",
+		"scheduleID": scheduleID,
 	})
 }
 
-// parseETA parses the ETA string into a time.Time object
-func parseETA(eta string) (time.Time, error) {
-	parsedETA, err := time.Parse(time.RFC3339, eta)
+// parseTimestamp parses an RFC 3339 timestamp such as the ETA into a time.Time object
+func parseTimestamp(timestamp string) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
 		return time.Time{}, err
 	}
-	return parsedETA, nil
+	return parsed, nil
 }
@@ -0,0 +1,130 @@
+package mq
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	usecases "chat-backend-general/internal/usecases/mq"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// newQueueRouter serves the message queue endpoints publishing to queue.
+func newQueueRouter(queue usecases.MessageQueueUseCase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewMessageQueueHandler(queue)
+	r := gin.New()
+	r.POST("/queue/publish", handler.PublishMessage)
+	r.DELETE("/queue/scheduled/:id", handler.CancelScheduledMessage)
+	return r
+}
+
+func TestMessageQueueHandler_PublishMessage(t *testing.T) {
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	soon := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	later := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		expires bool
+	}{
+		{name: "plain", body: `{"task": "tasks.add", "args": [2, 3]}`, status: http.StatusOK},
+		{name: "expires", body: `{"task": "tasks.add", "expires": "` + later + `"}`, status: http.StatusOK, expires: true},
+		{name: "expires after ETA", body: `{"task": "tasks.add", "eta": "` + soon + `", "expires": "` + later + `"}`, status: http.StatusOK, expires: true},
+		{name: "expired", body: `{"task": "tasks.add", "expires": "` + past + `"}`, status: http.StatusBadRequest},
+		{name: "expires before ETA", body: `{"task": "tasks.add", "eta": "` + later + `", "expires": "` + soon + `"}`, status: http.StatusBadRequest},
+		{name: "priority", body: `{"task": "tasks.add", "priority": 9}`, status: http.StatusOK},
+		{name: "priority out of range", body: `{"task": "tasks.add", "priority": 10}`, status: http.StatusBadRequest},
+		{name: "negative priority", body: `{"task": "tasks.add", "priority": -1}`, status: http.StatusBadRequest},
+		{name: "invalid expires", body: `{"task": "tasks.add", "expires": "tomorrow"}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewMemoryMessageQueue()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/queue/publish", strings.NewReader(tt.body))
+			newQueueRouter(usecases.NewMessageQueueUseCase(queue)).ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("POST /queue/publish = %d %s, want %d", w.Code, w.Body, tt.status)
+			}
+			messages := queue.Messages("default")
+			if tt.status != http.StatusOK {
+				if len(messages) != 0 {
+					t.Errorf("published %+v, want nothing", messages)
+				}
+				return
+			}
+			if len(messages) != 1 || (messages[0].Expires != nil) != tt.expires {
+				t.Errorf("published %+v, want one message with expires %v", messages, tt.expires)
+			}
+		})
+	}
+}
+
+func TestMessageQueueHandler_CancelScheduledMessage(t *testing.T) {
+	queue := NewMemoryMessageQueue()
+	r := newQueueRouter(usecases.NewMessageQueueUseCase(queue))
+
+	publish := func(target, body string) (string, string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		var response struct {
+			MessageID  string `json:"messageID"`
+			ScheduleID string `json:"scheduleID"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("POST %s = %d %s", target, w.Code, w.Body)
+		}
+		return response.MessageID, response.ScheduleID
+	}
+	cancel := func(target string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, target, nil))
+		return w.Code
+	}
+
+	eta := `{"task": "tasks.add", "eta": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}`
+	_, scheduled := publish("/queue/publish", eta)
+	_, other := publish("/queue/publish?queueName=other", eta)
+	immediate, immediateSchedule := publish("/queue/publish", `{"task": "tasks.add"}`)
+	if scheduled == "" || immediateSchedule != "" {
+		t.Fatalf("schedule IDs = %q, %q, want one for the scheduled message only", scheduled, immediateSchedule)
+	}
+
+	if status := cancel("/queue/scheduled/" + scheduled); status != http.StatusOK {
+		t.Errorf("DELETE scheduled message = %d, want %d", status, http.StatusOK)
+	}
+	if messages := queue.Messages("default"); len(messages) != 1 || messages[0].ID != immediate {
+		t.Errorf("queue = %+v, want only the immediate message", messages)
+	}
+	for _, target := range []string{"/queue/scheduled/" + scheduled, "/queue/scheduled/" + immediate, "/queue/scheduled/unknown", "/queue/scheduled/" + other} {
+		if status := cancel(target); status != http.StatusNotFound {
+			t.Errorf("DELETE %s = %d, want %d", target, status, http.StatusNotFound)
+		}
+	}
+	if status := cancel("/queue/scheduled/" + other + "?queueName=other"); status != http.StatusOK {
+		t.Errorf("DELETE scheduled message of other queue = %d, want %d", status, http.StatusOK)
+	}
+
+	server := miniredis.RunT(t)
+	redisQueue, err := NewRedisAdapter("redis://"+server.Addr(), "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRedisAdapter() error = %v", err)
+	}
+	defer redisQueue.Close()
+	w := httptest.NewRecorder()
+	newQueueRouter(usecases.NewMessageQueueUseCase(redisQueue)).ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/queue/scheduled/"+scheduled, nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("DELETE on Redis = %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,95 @@
+package mq
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// redisPrioritySeparator separates a queue name from the priority of its sub-queue in
+// the keys of kombu's Redis transport.
+const redisPrioritySeparator = "\x06\x16"
+
+// RedisAdapter publishes Celery tasks to Redis as kombu's Redis transport does: each
+// queue is a list, and tasks are pushed onto its head in the transport's JSON envelope.
+type RedisAdapter struct {
+	client    *redis.Client
+	keyPrefix string
+	origin    string
+	logger    *zap.Logger
+}
+
+// NewRedisAdapter initializes a new RedisAdapter for the Redis server at url, e.g.
+// redis://localhost:6379/0. keyPrefix is prepended to the queue keys, like kombu's
+// global_keyprefix transport option.
+func NewRedisAdapter(url, keyPrefix string, logger *zap.Logger) (*RedisAdapter, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		logger.Error("Invalid Redis URL", zap.Error(err))
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	return &RedisAdapter{
+		client:    redis.NewClient(options),
+		keyPrefix: keyPrefix,
+		origin:    celeryOrigin(),
+		logger:    logger,
+	}, nil
+}
+
+// PublishMessage pushes a message onto the list of the specified queue, or of its
+// priority sub-queue when the message has a priority below the highest. Kombu consumes
+// lower priority numbers first, so the priority is inverted; messages without priority
+// go to the queue itself, as Celery sends them. Redis lists can neither delay nor
+// expire items: the worker holds back tasks with an ETA and discards expired ones.
+func (a *RedisAdapter) PublishMessage(queueName string, message domain.CeleryMessage) error {
+	if _, err := timeToLive(message, time.Now()); err != nil {
+		return err
+	}
+	task, err := newCeleryTask(message, a.origin)
+	if err != nil {
+		a.logger.Error("Failed to encode message", zap.Error(err))
+		return err
+	}
+	redisPriority := 0
+	if priority, ok := messagePriority(message); ok {
+		redisPriority = domain.MaxCeleryPriority - priority
+	}
+	messageBytes, err := json.Marshal(task.envelope(queueName, redisPriority))
+	if err != nil {
+		a.logger.Error("Failed to marshal message", zap.Error(err))
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	key := a.keyPrefix + redisQueueKey(queueName, redisPriority)
+	if err := a.client.LPush(context.Background(), key, messageBytes).Err(); err != nil {
+		a.logger.Error("Failed to send message", zap.Error(err), zap.String("queueName", queueName))
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	a.logger.Info("Message sent successfully", zap.String("queueName", queueName))
+	return nil
+}
+
+// Close closes the connections to Redis.
+func (a *RedisAdapter) Close() error {
+	return a.client.Close()
+}
+
+// redisQueueKey returns the key of the list holding the messages of queue with priority,
+// on kombu's scale.
+// Like kombu, priorities are clamped to 0-9 and rounded down to the steps 0, 3, 6 and 9;
+// priority 0 is the queue itself and workers consume lower steps first.
+func redisQueueKey(queue string, priority int) string {
+	step := min(max(priority, 0), 9) / 3 * 3
+	if step == 0 {
+		return queue
+	}
+	return queue + redisPrioritySeparator + strconv.Itoa(step)
+}
@@ -0,0 +1,124 @@
+package mq
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/zap"
+)
+
+func TestRedisQueueKey(t *testing.T) {
+	tests := []struct {
+		priority int
+		want     string
+	}{
+		{priority: -1, want: "celery"},
+		{priority: 0, want: "celery"},
+		{priority: 2, want: "celery"},
+		{priority: 3, want: "celery\x06\x163"},
+		{priority: 5, want: "celery\x06\x163"},
+		{priority: 6, want: "celery\x06\x166"},
+		{priority: 9, want: "celery\x06\x169"},
+		{priority: 200, want: "celery\x06\x169"},
+	}
+
+	for _, tt := range tests {
+		if got := redisQueueKey("celery", tt.priority); got != tt.want {
+			t.Errorf("redisQueueKey(celery, %d) = %q, want %q", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestRedisAdapter_PublishMessage(t *testing.T) {
+	server := miniredis.RunT(t)
+	adapter, err := NewRedisAdapter("redis://"+server.Addr()+"/0", "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRedisAdapter() error = %v", err)
+	}
+	defer adapter.Close()
+
+	highest, low := domain.MaxCeleryPriority, 2
+	first := domain.NewCeleryMessage("tasks.add", []interface{}{2, 3}, nil)
+	second := domain.NewCeleryMessage("tasks.add", []interface{}{4, 5}, nil)
+	second.Priority = &highest
+	background := domain.NewCeleryMessage("tasks.add", []interface{}{6, 7}, nil)
+	background.Priority = &low
+	for _, message := range []domain.CeleryMessage{first, second, background} {
+		if err := adapter.PublishMessage("default", message); err != nil {
+			t.Fatalf("PublishMessage() error = %v", err)
+		}
+	}
+
+	// Workers pop from the tail, so the oldest message comes first. Messages without
+	// priority share the queue with those of the highest priority.
+	queued, err := server.List("default")
+	if err != nil || len(queued) != 2 {
+		t.Fatalf("default list = %v, %v, want 2 messages", queued, err)
+	}
+	for i, want := range []domain.CeleryMessage{first, second} {
+		raw := queued[len(queued)-1-i]
+		var envelope celeryEnvelope
+		if err := json.Unmarshal([]byte(raw), &envelope); err != nil {
+			t.Fatalf("decoding %s: %v", raw, err)
+		}
+		if envelope.Headers.ID != want.ID || envelope.Headers.Task != "tasks.add" || envelope.Properties.BodyEncoding != "base64" ||
+			envelope.Properties.DeliveryInfo.RoutingKey != "default" || envelope.ContentType != "application/json" {
+			t.Errorf("message %d = %s, want task %s routed to default", i, raw, want.ID)
+		}
+		body, err := base64.StdEncoding.DecodeString(envelope.Body)
+		if err != nil {
+			t.Fatalf("decoding body: %v", err)
+		}
+		var decoded []interface{}
+		if err := json.Unmarshal(body, &decoded); err != nil || len(decoded) != 3 {
+			t.Errorf("body = %s, want [args, kwargs, embed]", body)
+		}
+	}
+
+	// Kombu consumes lower numbers first: priority 2 of 9 is 7 on its scale, in step 6.
+	prioritized, err := server.List("default\x06\x166")
+	if err != nil || len(prioritized) != 1 {
+		t.Fatalf("priority 6 list = %v, %v, want the background message", prioritized, err)
+	}
+	var envelope celeryEnvelope
+	if err := json.Unmarshal([]byte(prioritized[0]), &envelope); err != nil || envelope.Headers.ID != background.ID || envelope.Properties.Priority != 7 {
+		t.Errorf("priority 6 list = %s, want message %s with priority 7", prioritized[0], background.ID)
+	}
+}
+
+func TestRedisAdapter_KeyPrefix(t *testing.T) {
+	server := miniredis.RunT(t)
+	adapter, err := NewRedisAdapter("redis://"+server.Addr(), "tenant:", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRedisAdapter() error = %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.PublishMessage("ingest", domain.NewCeleryMessage("tasks.ingest_document", nil, nil)); err != nil {
+		t.Fatalf("PublishMessage() error = %v", err)
+	}
+	if !server.Exists("tenant:ingest") || server.Exists("ingest") {
+		t.Errorf("keys = %v, want tenant:ingest", server.Keys())
+	}
+}
+
+func TestRedisAdapter_Errors(t *testing.T) {
+	if _, err := NewRedisAdapter("localhost:6379", "", zap.NewNop()); err == nil {
+		t.Error("NewRedisAdapter(URL without scheme) error = nil, want error")
+	}
+
+	server := miniredis.RunT(t)
+	adapter, err := NewRedisAdapter("redis://"+server.Addr(), "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRedisAdapter() error = %v", err)
+	}
+	defer adapter.Close()
+	server.Close()
+	if err := adapter.PublishMessage("default", domain.NewCeleryMessage("tasks.add", nil, nil)); err == nil {
+		t.Error("PublishMessage() with Redis down error = nil, want error")
+	}
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryFileMetadataRepository keeps file metadata in process memory. It is used
+// when no database is configured, e.g. in local development and tests.
+type MemoryFileMetadataRepository struct {
+	mu      sync.RWMutex
+	records map[string]domain.FileRecord
+}
+
+// NewMemoryFileMetadataRepository creates an empty MemoryFileMetadataRepository.
+func NewMemoryFileMetadataRepository() *MemoryFileMetadataRepository {
+	return &MemoryFileMetadataRepository{records: map[string]domain.FileRecord{}}
+}
+
+// CreateFileRecord stores a new file record. It fails with domain.ErrVersionConflict when
+// the record's version of its document exists already.
+func (m *MemoryFileMetadataRepository) CreateFileRecord(ctx context.Context, record domain.FileRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.records {
+		if existing.Owner == record.Owner && existing.ChatID == record.ChatID &&
+			existing.OriginalName == record.OriginalName && existing.Version == record.Version {
+			return domain.ErrVersionConflict
+		}
+	}
+	m.records[record.ID] = record
+	return nil
+}
+
+// UpdateFileRecord replaces a stored file record.
+func (m *MemoryFileMetadataRepository) UpdateFileRecord(ctx context.Context, record domain.FileRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.records[record.ID]; !ok {
+		return domain.ErrFileRecordNotFound
+	}
+	m.records[record.ID] = record
+	return nil
+}
+
+// GetFileRecord returns the record of a file ID.
+func (m *MemoryFileMetadataRepository) GetFileRecord(ctx context.Context, id string) (domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.records[id]
+	if !ok {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return record, nil
+}
+
+// GetFileRecordByPath returns the newest record stored at path that is neither deleted nor purged.
+func (m *MemoryFileMetadataRepository) GetFileRecordByPath(ctx context.Context, path string) (domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var found *domain.FileRecord
+	for _, record := range m.records {
+		if record.StoragePath != path || record.Status == domain.FileStatusDeleted || record.Status == domain.FileStatusPurged {
+			continue
+		}
+		if found == nil || record.CreatedAt.After(found.CreatedAt) {
+			found = &record
+		}
+	}
+	if found == nil {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return *found, nil
+}
+
+// GetUsage sums the pending and stored files of owner, or of owner's chat when chatID is set.
+func (m *MemoryFileMetadataRepository) GetUsage(ctx context.Context, owner, chatID string) (domain.Usage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var usage domain.Usage
+	for _, record := range m.records {
+		if record.Owner != owner || (chatID != "" && record.ChatID != chatID) {
+			continue
+		}
+		if record.Status == domain.FileStatusPending || record.Status == domain.FileStatusStored {
+			usage.Files++
+			usage.Bytes += record.Size
+		}
+	}
+	return usage, nil
+}
+
+// ListFileRecords returns the records of owner's chat, oldest first.
+func (m *MemoryFileMetadataRepository) ListFileRecords(ctx context.Context, owner, chatID string) ([]domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := []domain.FileRecord{}
+	for _, record := range m.records {
+		if record.Owner == owner && record.ChatID == chatID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+// FindFileRecordByChecksum returns the oldest stored record of owner with checksum.
+func (m *MemoryFileMetadataRepository) FindFileRecordByChecksum(ctx context.Context, owner, checksum string) (domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var found *domain.FileRecord
+	for _, record := range m.records {
+		if record.Owner != owner || record.Checksum != checksum || record.Status != domain.FileStatusStored {
+			continue
+		}
+		if found == nil || record.CreatedAt.Before(found.CreatedAt) {
+			found = &record
+		}
+	}
+	if found == nil {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return *found, nil
+}
+
+// CountContentReferences counts the pending, stored and deleted records whose content is at path.
+func (m *MemoryFileMetadataRepository) CountContentReferences(ctx context.Context, path string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var count int64
+	for _, record := range m.records {
+		switch record.Status {
+		case domain.FileStatusPending, domain.FileStatusStored, domain.FileStatusDeleted:
+			if record.ContentPath() == path {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// ListDocumentVersions returns the records of owner's chat named name, newest version first.
+func (m *MemoryFileMetadataRepository) ListDocumentVersions(ctx context.Context, owner, chatID, name string) ([]domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := []domain.FileRecord{}
+	for _, record := range m.records {
+		if record.Owner == owner && record.ChatID == chatID && record.OriginalName == name {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Version > records[j].Version })
+	return records, nil
+}
+
+// ListPurgeableFileRecords returns up to limit deleted records, deleted at or before deletedBy, oldest deletion first.
+func (m *MemoryFileMetadataRepository) ListPurgeableFileRecords(ctx context.Context, deletedBy time.Time, limit int) ([]domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := []domain.FileRecord{}
+	for _, record := range m.records {
+		if record.Status == domain.FileStatusDeleted && record.DeletedAt != nil && !record.DeletedAt.After(deletedBy) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].DeletedAt.Before(*records[j].DeletedAt) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// ListStalePendingFileRecords returns up to limit pending records, created at or before createdBy, oldest first.
+func (m *MemoryFileMetadataRepository) ListStalePendingFileRecords(ctx context.Context, createdBy time.Time, limit int) ([]domain.FileRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := []domain.FileRecord{}
+	for _, record := range m.records {
+		if record.Status == domain.FileStatusPending && !record.CreatedAt.After(createdBy) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryOutboxRepository keeps outbox messages in process memory, next to the file
+// metadata of a MemoryFileMetadataRepository. Messages are lost when the process exits.
+type MemoryOutboxRepository struct {
+	mu       sync.Mutex
+	metadata *MemoryFileMetadataRepository
+	messages map[string]domain.OutboxMessage
+}
+
+// NewMemoryOutboxRepository creates an empty MemoryOutboxRepository updating file records in metadata.
+func NewMemoryOutboxRepository(metadata *MemoryFileMetadataRepository) *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{metadata: metadata, messages: map[string]domain.OutboxMessage{}}
+}
+
+// UpdateFileRecordWithMessage updates record and adds message once the record is updated.
+func (m *MemoryOutboxRepository) UpdateFileRecordWithMessage(ctx context.Context, record domain.FileRecord, message domain.OutboxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.metadata.UpdateFileRecord(ctx, record); err != nil {
+		return err
+	}
+	m.messages[message.ID] = message
+	return nil
+}
+
+// ClaimOutboxMessages moves the next attempt of up to limit due messages to the end of the lease.
+func (m *MemoryOutboxRepository) ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := []domain.OutboxMessage{}
+	for _, message := range m.messages {
+		if !message.NextAttemptAt.After(now) {
+			due = append(due, message)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].Attempts++
+		due[i].NextAttemptAt = now.Add(lease)
+		m.messages[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+// RetryOutboxMessage schedules the next attempt of a message and records why the last one failed.
+func (m *MemoryOutboxRepository) RetryOutboxMessage(ctx context.Context, id string, nextAttemptAt time.Time, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	message, ok := m.messages[id]
+	if !ok {
+		return domain.ErrOutboxMessageNotFound
+	}
+	message.NextAttemptAt, message.LastError = nextAttemptAt, lastError
+	m.messages[id] = message
+	return nil
+}
+
+// DeleteOutboxMessage removes a published message.
+func (m *MemoryOutboxRepository) DeleteOutboxMessage(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.messages[id]; !ok {
+		return domain.ErrOutboxMessageNotFound
+	}
+	delete(m.messages, id)
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryUploadSessionStore keeps upload sessions in process memory. Sessions are
+// lost on restart, so clients then have to start their uploads over.
+type MemoryUploadSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]domain.UploadSession
+}
+
+// NewMemoryUploadSessionStore creates an empty MemoryUploadSessionStore.
+func NewMemoryUploadSessionStore() *MemoryUploadSessionStore {
+	return &MemoryUploadSessionStore{sessions: map[string]domain.UploadSession{}}
+}
+
+// CreateSession stores a new session.
+func (m *MemoryUploadSessionStore) CreateSession(ctx context.Context, session domain.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purgeExpired()
+	m.sessions[session.ID] = session
+	return nil
+}
+
+// GetSession returns a session that has not expired yet.
+func (m *MemoryUploadSessionStore) GetSession(ctx context.Context, id string) (domain.UploadSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return domain.UploadSession{}, domain.ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// UpdateSession replaces a stored session.
+func (m *MemoryUploadSessionStore) UpdateSession(ctx context.Context, session domain.UploadSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[session.ID]; !ok {
+		return domain.ErrUploadNotFound
+	}
+	m.sessions[session.ID] = session
+	return nil
+}
+
+// DeleteSession removes a session.
+func (m *MemoryUploadSessionStore) DeleteSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[id]; !ok {
+		return domain.ErrUploadNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+// purgeExpired drops expired sessions; the caller must hold m.mu.
+func (m *MemoryUploadSessionStore) purgeExpired() {
+	now := time.Now()
+	for id, session := range m.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(m.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// PostgresFileMetadataRepository stores file metadata in the files table.
+type PostgresFileMetadataRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresFileMetadataRepository creates a PostgresFileMetadataRepository.
+func NewPostgresFileMetadataRepository(db *sql.DB) *PostgresFileMetadataRepository {
+	return &PostgresFileMetadataRepository{db: db}
+}
+
+const fileRecordColumns = `id, owner, chat_id, original_name, content_type, size, checksum, storage_path, status, created_at, updated_at,
+	scan_verdict, scan_signature, scanned_at, blob_path, version, deleted_at`
+
+// CreateFileRecord inserts a new file record. It fails with domain.ErrVersionConflict when
+// the record's version of its document exists already.
+func (p *PostgresFileMetadataRepository) CreateFileRecord(ctx context.Context, record domain.FileRecord) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO files (`+fileRecordColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		record.ID, record.Owner, record.ChatID, record.OriginalName, record.ContentType, record.Size,
+		record.Checksum, record.StoragePath, record.Status, record.CreatedAt, record.UpdatedAt,
+		record.ScanVerdict, record.ScanSignature, record.ScannedAt, record.BlobPath, record.Version, record.DeletedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "files_document_version_idx" {
+		return domain.ErrVersionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert file record: %w", err)
+	}
+	return nil
+}
+
+// UpdateFileRecord overwrites the mutable fields of a file record.
+func (p *PostgresFileMetadataRepository) UpdateFileRecord(ctx context.Context, record domain.FileRecord) error {
+	return updateFileRecord(ctx, p.db, record)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// updateFileRecord overwrites the mutable fields of a file record through db.
+func updateFileRecord(ctx context.Context, db execer, record domain.FileRecord) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE files
+		SET content_type = $2, size = $3, checksum = $4, storage_path = $5, status = $6, updated_at = $7,
+			scan_verdict = $8, scan_signature = $9, scanned_at = $10, blob_path = $11, deleted_at = $12
+		WHERE id = $1`,
+		record.ID, record.ContentType, record.Size, record.Checksum, record.StoragePath, record.Status, record.UpdatedAt,
+		record.ScanVerdict, record.ScanSignature, record.ScannedAt, record.BlobPath, record.DeletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update file record: %w", err)
+	}
+	return expectOneRow(res, domain.ErrFileRecordNotFound)
+}
+
+// GetFileRecord returns the record of a file ID.
+func (p *PostgresFileMetadataRepository) GetFileRecord(ctx context.Context, id string) (domain.FileRecord, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+fileRecordColumns+` FROM files WHERE id = $1`, id)
+	return scanFileRecord(row)
+}
+
+// GetFileRecordByPath returns the newest record stored at path that is neither deleted nor purged.
+func (p *PostgresFileMetadataRepository) GetFileRecordByPath(ctx context.Context, path string) (domain.FileRecord, error) {
+	row := p.db.QueryRowContext(ctx, `
+		SELECT `+fileRecordColumns+` FROM files
+		WHERE storage_path = $1 AND status NOT IN ($2, $3)
+		ORDER BY created_at DESC
+		LIMIT 1`, path, domain.FileStatusDeleted, domain.FileStatusPurged)
+	return scanFileRecord(row)
+}
+
+// GetUsage sums the pending and stored files of owner, or of owner's chat when chatID is set.
+func (p *PostgresFileMetadataRepository) GetUsage(ctx context.Context, owner, chatID string) (domain.Usage, error) {
+	var usage domain.Usage
+	err := p.db.QueryRowContext(ctx, `
+		SELECT count(*), coalesce(sum(size), 0) FROM files
+		WHERE owner = $1 AND ($2 = '' OR chat_id = $2) AND status IN ($3, $4)`,
+		owner, chatID, domain.FileStatusPending, domain.FileStatusStored).Scan(&usage.Files, &usage.Bytes)
+	if err != nil {
+		return domain.Usage{}, fmt.Errorf("failed to read usage: %w", err)
+	}
+	return usage, nil
+}
+
+// ListFileRecords returns the records of owner's chat, oldest first.
+func (p *PostgresFileMetadataRepository) ListFileRecords(ctx context.Context, owner, chatID string) ([]domain.FileRecord, error) {
+	return p.queryFileRecords(ctx, `
+		SELECT `+fileRecordColumns+` FROM files
+		WHERE owner = $1 AND chat_id = $2
+		ORDER BY created_at, id`, owner, chatID)
+}
+
+// FindFileRecordByChecksum returns the oldest stored record of owner with checksum.
+func (p *PostgresFileMetadataRepository) FindFileRecordByChecksum(ctx context.Context, owner, checksum string) (domain.FileRecord, error) {
+	row := p.db.QueryRowContext(ctx, `
+		SELECT `+fileRecordColumns+` FROM files
+		WHERE owner = $1 AND checksum = $2 AND status = $3
+		ORDER BY created_at
+		LIMIT 1`, owner, checksum, domain.FileStatusStored)
+	return scanFileRecord(row)
+}
+
+// CountContentReferences counts the pending, stored and deleted records whose content is at path.
+func (p *PostgresFileMetadataRepository) CountContentReferences(ctx context.Context, path string) (int64, error) {
+	var count int64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT count(*) FROM files
+		WHERE (blob_path = $1 OR (blob_path = '' AND storage_path = $1)) AND status IN ($2, $3, $4)`,
+		path, domain.FileStatusPending, domain.FileStatusStored, domain.FileStatusDeleted).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count content references: %w", err)
+	}
+	return count, nil
+}
+
+// ListDocumentVersions returns the records of owner's chat named name, newest version first.
+func (p *PostgresFileMetadataRepository) ListDocumentVersions(ctx context.Context, owner, chatID, name string) ([]domain.FileRecord, error) {
+	return p.queryFileRecords(ctx, `
+		SELECT `+fileRecordColumns+` FROM files
+		WHERE owner = $1 AND chat_id = $2 AND original_name = $3
+		ORDER BY version DESC`, owner, chatID, name)
+}
+
+// ListPurgeableFileRecords returns up to limit deleted records, deleted at or before deletedBy, oldest deletion first.
+func (p *PostgresFileMetadataRepository) ListPurgeableFileRecords(ctx context.Context, deletedBy time.Time, limit int) ([]domain.FileRecord, error) {
+	return p.queryFileRecords(ctx, `
+		SELECT `+fileRecordColumns+` FROM files
+		WHERE status = $1 AND deleted_at <= $2
+		ORDER BY deleted_at
+		LIMIT $3`, domain.FileStatusDeleted, deletedBy, limit)
+}
+
+// ListStalePendingFileRecords returns up to limit pending records, created at or before createdBy, oldest first.
+func (p *PostgresFileMetadataRepository) ListStalePendingFileRecords(ctx context.Context, createdBy time.Time, limit int) ([]domain.FileRecord, error) {
+	return p.queryFileRecords(ctx, `
+		SELECT `+fileRecordColumns+` FROM files
+		WHERE status = $1 AND created_at <= $2
+		ORDER BY created_at
+		LIMIT $3`, domain.FileStatusPending, createdBy, limit)
+}
+
+// queryFileRecords runs a query selecting fileRecordColumns and reads every row.
+func (p *PostgresFileMetadataRepository) queryFileRecords(ctx context.Context, query string, args ...any) ([]domain.FileRecord, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []domain.FileRecord{}
+	for rows.Next() {
+		record, err := scanFileRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list file records: %w", err)
+	}
+	return records, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFileRecord(row rowScanner) (domain.FileRecord, error) {
+	var r domain.FileRecord
+	err := row.Scan(&r.ID, &r.Owner, &r.ChatID, &r.OriginalName, &r.ContentType, &r.Size,
+		&r.Checksum, &r.StoragePath, &r.Status, &r.CreatedAt, &r.UpdatedAt,
+		&r.ScanVerdict, &r.ScanSignature, &r.ScannedAt, &r.BlobPath, &r.Version, &r.DeletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	if err != nil {
+		return domain.FileRecord{}, fmt.Errorf("failed to read file record: %w", err)
+	}
+	return r, nil
+}
+
+// expectOneRow returns notFound when a statement affected no rows.
+func expectOneRow(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PostgresOutboxRepository keeps outbox messages in the outbox table, next to the files
+// table they are written with.
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresOutboxRepository creates a PostgresOutboxRepository.
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+// UpdateFileRecordWithMessage updates record and inserts message in one transaction.
+func (p *PostgresOutboxRepository) UpdateFileRecordWithMessage(ctx context.Context, record domain.FileRecord, message domain.OutboxMessage) error {
+	body, err := json.Marshal(message.Message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox message: %w", err)
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateFileRecord(ctx, tx, record); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO outbox (id, queue, message, attempts, last_error, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		message.ID, message.Queue, body, message.Attempts, message.LastError, message.CreatedAt, message.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutboxMessages moves the next attempt of up to limit due messages to the end of
+// the lease. Rows locked by a concurrent claim are skipped, so relays running in
+// several processes never claim the same message at once.
+func (p *PostgresOutboxRepository) ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxMessage, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		UPDATE outbox SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, queue, message, attempts, last_error, created_at, next_attempt_at`,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []domain.OutboxMessage{}
+	for rows.Next() {
+		var m domain.OutboxMessage
+		var body []byte
+		if err := rows.Scan(&m.ID, &m.Queue, &body, &m.Attempts, &m.LastError, &m.CreatedAt, &m.NextAttemptAt); err != nil {
+			return nil, fmt.Errorf("failed to read outbox message: %w", err)
+		}
+		if err := json.Unmarshal(body, &m.Message); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outbox message %s: %w", m.ID, err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+// RetryOutboxMessage schedules the next attempt of a message and records why the last one failed.
+func (p *PostgresOutboxRepository) RetryOutboxMessage(ctx context.Context, id string, nextAttemptAt time.Time, lastError string) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE outbox SET next_attempt_at = $2, last_error = $3 WHERE id = $1`, id, nextAttemptAt, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox message: %w", err)
+	}
+	return expectOneRow(res, domain.ErrOutboxMessageNotFound)
+}
+
+// DeleteOutboxMessage removes a published message.
+func (p *PostgresOutboxRepository) DeleteOutboxMessage(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM outbox WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete outbox message: %w", err)
+	}
+	return expectOneRow(res, domain.ErrOutboxMessageNotFound)
+}
@@ -0,0 +1,142 @@
+// internal/adaptors/scanning/clamd_scanner.go
+package scanning
+
+import (
+	"bufio"
+	"chat-backend-general/internal/domain"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the chunks streamed to clamd, well below its default
+// StreamMaxLength.
+const clamdChunkSize = 64 * 1024
+
+// ClamdScanner scans files with a ClamAV daemon over its INSTREAM command.
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a ClamdScanner for a daemon listening at address, given as
+// tcp://host:port, unix:///path/to/clamd.sock or a bare host:port. Each scan is cut
+// off after timeout.
+func NewClamdScanner(address string, timeout time.Duration) (*ClamdScanner, error) {
+	network, addr := "tcp", address
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		network, addr = scheme, rest
+	}
+	if network != "tcp" && network != "unix" {
+		return nil, fmt.Errorf("unsupported clamd address %q, want tcp:// or unix://", address)
+	}
+	if addr == "" {
+		return nil, errors.New("clamd address is empty")
+	}
+	return &ClamdScanner{network: network, address: addr, timeout: timeout}, nil
+}
+
+// Scan streams content to clamd and reports its verdict.
+func (s *ClamdScanner) Scan(ctx context.Context, content io.Reader) (domain.ScanResult, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return domain.ScanResult{}, fmt.Errorf("connecting to clamd: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := s.stream(conn, content); err != nil {
+		// clamd replies and hangs up when it refuses the stream, e.g. when it exceeds
+		// StreamMaxLength, so its reply explains the failure better than err does.
+		if reply, readErr := readReply(conn); readErr == nil {
+			if _, replyErr := parseReply(reply); replyErr != nil {
+				return domain.ScanResult{}, replyErr
+			}
+		}
+		return domain.ScanResult{}, fmt.Errorf("streaming to clamd: %w", err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return domain.ScanResult{}, fmt.Errorf("reading clamd reply: %w", err)
+	}
+	return parseReply(reply)
+}
+
+// stream sends content as an INSTREAM command: length-prefixed chunks ended by an
+// empty one.
+func (s *ClamdScanner) stream(conn net.Conn, content io.Reader) error {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	buf := make([]byte, clamdChunkSize)
+	var size [4]byte
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, werr := w.Write(size[:]); werr != nil {
+				return werr
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// readReply reads a NUL-terminated reply, as sent for z-prefixed commands.
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", err
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseReply interprets replies such as "stream: OK", "stream: Eicar-Signature FOUND"
+// and "INSTREAM size limit exceeded. ERROR".
+func parseReply(reply string) (domain.ScanResult, error) {
+	switch {
+	case strings.HasSuffix(reply, " ERROR"):
+		return domain.ScanResult{}, fmt.Errorf("clamd: %s", strings.TrimSuffix(reply, " ERROR"))
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(reply, " FOUND")
+		if _, name, ok := strings.Cut(signature, ": "); ok {
+			signature = name
+		}
+		return domain.ScanResult{Verdict: domain.ScanVerdictInfected, Signature: signature}, nil
+	case strings.HasSuffix(reply, ": OK"):
+		return domain.ScanResult{Verdict: domain.ScanVerdictClean}, nil
+	default:
+		return domain.ScanResult{}, fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+}
@@ -0,0 +1,161 @@
+package scanning
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// eicar is the standard antivirus test file.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd serves the INSTREAM command like clamd, flagging streams that contain
+// eicar and refusing streams longer than maxLength.
+func fakeClamd(t *testing.T, maxLength int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxLength)
+		}
+	}()
+	return "tcp://" + ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxLength int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var stream bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if stream.Len()+int(size) > maxLength {
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+		if _, err := io.CopyN(&stream, r, int64(size)); err != nil {
+			return
+		}
+	}
+
+	if strings.Contains(stream.String(), eicar) {
+		io.WriteString(conn, "stream: Eicar-Signature FOUND\x00")
+		return
+	}
+	io.WriteString(conn, "stream: OK\x00")
+}
+
+func TestClamdScanner_Scan(t *testing.T) {
+	scanner, err := NewClamdScanner(fakeClamd(t, 1<<20), 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewClamdScanner() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected domain.ScanResult
+	}{
+		{name: "clean", content: "hello world", expected: domain.ScanResult{Verdict: domain.ScanVerdictClean}},
+		{name: "empty", content: "", expected: domain.ScanResult{Verdict: domain.ScanVerdictClean}},
+		{name: "infected", content: eicar, expected: domain.ScanResult{Verdict: domain.ScanVerdictInfected, Signature: "Eicar-Signature"}},
+		{name: "infected across chunks", content: strings.Repeat("x", clamdChunkSize-10) + eicar,
+			expected: domain.ScanResult{Verdict: domain.ScanVerdictInfected, Signature: "Eicar-Signature"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scanner.Scan(context.Background(), strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("ClamdScanner.Scan() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ClamdScanner.Scan() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClamdScanner_ReportsDaemonErrors(t *testing.T) {
+	scanner, err := NewClamdScanner(fakeClamd(t, 16), 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewClamdScanner() error = %v", err)
+	}
+
+	_, err = scanner.Scan(context.Background(), strings.NewReader(strings.Repeat("x", 64)))
+	if err == nil || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Errorf("ClamdScanner.Scan() error = %v, want size limit error", err)
+	}
+}
+
+func TestClamdScanner_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	scanner, err := NewClamdScanner(address, time.Second)
+	if err != nil {
+		t.Fatalf("NewClamdScanner() error = %v", err)
+	}
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("hello")); err == nil {
+		t.Error("ClamdScanner.Scan() error = nil, want connection error")
+	}
+}
+
+func TestNewClamdScanner(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		addr    string
+		wantErr bool
+	}{
+		{address: "tcp://clamav:3310", network: "tcp", addr: "clamav:3310"},
+		{address: "unix:///var/run/clamav/clamd.ctl", network: "unix", addr: "/var/run/clamav/clamd.ctl"},
+		{address: "localhost:3310", network: "tcp", addr: "localhost:3310"},
+		{address: "http://clamav:3310", wantErr: true},
+		{address: "tcp://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			scanner, err := NewClamdScanner(tt.address, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClamdScanner(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if err == nil && (scanner.network != tt.network || scanner.address != tt.addr) {
+				t.Errorf("NewClamdScanner(%q) = %s %s, want %s %s", tt.address, scanner.network, scanner.address, tt.network, tt.addr)
+			}
+		})
+	}
+}
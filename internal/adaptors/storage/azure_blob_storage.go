@@ -1,13 +1,26 @@
 package storage
 
 import (
+	"bytes"
 	"chat-backend-general/config"
 	"chat-backend-general/internal/domain"
+	infraStorage "chat-backend-general/internal/infra/storage"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/http"
+	pathpkg "path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"go.uber.org/zap"
 )
 
@@ -23,19 +36,15 @@ func NewBlobStorageAdapter(cfg *config.Config, logger *zap.Logger) (*BlobStorage
 	if cfg == nil {
 		return nil, errors.New("config is nil")
 	}
-
-	// Initialize Azure Blob Storage client
-	accountURL := fmt.Sprintf("https://%s.blob.core.windows.net", cfg.Storage.Config.AccountName)
-	cred, err := azblob.NewSharedKeyCredential(cfg.Storage.Config.AccountName, cfg.Storage.Config.ApiKey)
-	if err != nil {
-		logger.Error("Failed to create shared key credential", zap.Error(err))
-		return nil, fmt.Errorf("failed to create shared key credential: %w", err)
+	if cfg.Storage.Config.BucketName == "" {
+		return nil, errors.New("storage container name is empty")
 	}
 
-	blobService, err := azblob.NewClientWithSharedKeyCredential(accountURL, cred, nil)
+	// Initialize Azure Blob Storage client
+	blobService, err := infraStorage.NewAzureBlobClient(cfg.Storage.Config)
 	if err != nil {
 		logger.Error("Failed to create Azure Blob Storage client", zap.Error(err))
-		return nil, fmt.Errorf("failed to create Azure Blob Storage client: %w", err)
+		return nil, err
 	}
 
 	return &BlobStorageAdapter{
@@ -45,8 +54,8 @@ func NewBlobStorageAdapter(cfg *config.Config, logger *zap.Logger) (*BlobStorage
 	}, nil
 }
 
-// UploadFile uploads a file to Azure Blob Storage.
-func (b *BlobStorageAdapter) UploadFile(ctx context.Context, file domain.UploadedFile) error {
+// SaveFile uploads a file to Azure Blob Storage.
+func (b *BlobStorageAdapter) SaveFile(ctx context.Context, file domain.UploadedFile) error {
 	if b.blobService == nil {
 		b.logger.Error("blobService is nil")
 		return errors.New("blobService is nil")
@@ -56,7 +65,9 @@ func (b *BlobStorageAdapter) UploadFile(ctx context.Context, file domain.Uploade
 		return errors.New("file is nil")
 	}
 
-	uploadOpt := &azblob.UploadStreamOptions{}
+	uploadOpt := &azblob.UploadStreamOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &file.ContentType},
+	}
 	_, err := b.blobService.UploadStream(ctx, b.containerName, file.Path, file.File, uploadOpt)
 	if err != nil {
 		b.logger.Error("Error uploading file", zap.Error(err), zap.String("path", file.Path))
@@ -66,3 +77,222 @@ func (b *BlobStorageAdapter) UploadFile(ctx context.Context, file domain.Uploade
 	b.logger.Info("File uploaded successfully", zap.String("path", file.Path))
 	return nil
 }
+
+// RecordChecksum stores the SHA-256 checksum of a blob in its metadata.
+func (b *BlobStorageAdapter) RecordChecksum(ctx context.Context, path, checksum string) error {
+	blobClient := b.blobService.ServiceClient().NewContainerClient(b.containerName).NewBlobClient(path)
+	props, err := blobClient.GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return domain.ErrFileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read file properties: %w", err)
+	}
+
+	// SetMetadata replaces all metadata, so keep what is already there.
+	metadata := props.Metadata
+	if metadata == nil {
+		metadata = map[string]*string{}
+	}
+	metadata[checksumMetadataKey] = &checksum
+	if _, err := blobClient.SetMetadata(ctx, metadata, nil); err != nil {
+		b.logger.Error("Error recording file checksum", zap.Error(err), zap.String("path", path))
+		return fmt.Errorf("failed to record file checksum: %w", err)
+	}
+	return nil
+}
+
+// StatFile returns the properties of a blob without downloading it.
+func (b *BlobStorageAdapter) StatFile(ctx context.Context, path string) (domain.FileInfo, error) {
+	blobClient := b.blobService.ServiceClient().NewContainerClient(b.containerName).NewBlobClient(path)
+	props, err := blobClient.GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return domain.FileInfo{}, domain.ErrFileNotFound
+	}
+	if err != nil {
+		b.logger.Error("Error reading file properties", zap.Error(err), zap.String("path", path))
+		return domain.FileInfo{}, fmt.Errorf("failed to read file properties: %w", err)
+	}
+
+	info := domain.FileInfo{
+		Name:         pathpkg.Base(path),
+		Path:         path,
+		ContentType:  deref(props.ContentType),
+		Size:         deref(props.ContentLength),
+		LastModified: deref(props.LastModified),
+	}
+	if props.ETag != nil {
+		info.ETag = string(*props.ETag)
+	}
+	return info, nil
+}
+
+// GetFile opens a blob, or a range of it, for streaming. The caller must close the returned content.
+func (b *BlobStorageAdapter) GetFile(ctx context.Context, path string, rng *domain.ByteRange) (domain.StoredFile, error) {
+	opts := &azblob.DownloadStreamOptions{}
+	if rng != nil {
+		opts.Range = blob.HTTPRange{Offset: rng.Offset, Count: rng.Length}
+	}
+
+	resp, err := b.blobService.DownloadStream(ctx, b.containerName, path, opts)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return domain.StoredFile{}, domain.ErrFileNotFound
+	}
+	if err != nil {
+		b.logger.Error("Error downloading file", zap.Error(err), zap.String("path", path))
+		return domain.StoredFile{}, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	info := domain.FileInfo{
+		Name:         pathpkg.Base(path),
+		Path:         path,
+		ContentType:  deref(resp.ContentType),
+		Size:         deref(resp.ContentLength),
+		LastModified: deref(resp.LastModified),
+	}
+	if resp.ETag != nil {
+		info.ETag = string(*resp.ETag)
+	}
+	// For ranged reads Content-Length is the range; the full size follows the slash in Content-Range.
+	if resp.ContentRange != nil {
+		if i := strings.LastIndex(*resp.ContentRange, "/"); i >= 0 {
+			if total, err := strconv.ParseInt((*resp.ContentRange)[i+1:], 10, 64); err == nil {
+				info.Size = total
+			}
+		}
+	}
+
+	return domain.StoredFile{FileInfo: info, Content: resp.Body}, nil
+}
+
+// ListFiles lists the blobs whose path starts with prefix.
+func (b *BlobStorageAdapter) ListFiles(ctx context.Context, prefix string) ([]domain.FileInfo, error) {
+	files := []domain.FileInfo{}
+	pager := b.blobService.NewListBlobsFlatPager(b.containerName, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			b.logger.Error("Error listing files", zap.Error(err), zap.String("prefix", prefix))
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			info := domain.FileInfo{
+				Name: pathpkg.Base(*item.Name),
+				Path: *item.Name,
+			}
+			if props := item.Properties; props != nil {
+				info.ContentType = deref(props.ContentType)
+				info.Size = deref(props.ContentLength)
+				info.LastModified = deref(props.LastModified)
+				if props.ETag != nil {
+					info.ETag = string(*props.ETag)
+				}
+			}
+			files = append(files, info)
+		}
+	}
+
+	return files, nil
+}
+
+// DeleteFile removes a blob.
+func (b *BlobStorageAdapter) DeleteFile(ctx context.Context, path string) error {
+	_, err := b.blobService.DeleteBlob(ctx, b.containerName, path, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return domain.ErrFileNotFound
+	}
+	if err != nil {
+		b.logger.Error("Error deleting file", zap.Error(err), zap.String("path", path))
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	b.logger.Info("File deleted successfully", zap.String("path", path))
+	return nil
+}
+
+// SignURL returns a SAS URL for downloading or uploading a blob directly. A SAS cannot
+// limit the size of an upload, so size is only enforced when the upload is finalized.
+func (b *BlobStorageAdapter) SignURL(ctx context.Context, path string, op domain.SignedURLOperation, expiry time.Duration, size int64) (domain.SignedURL, error) {
+	signed := domain.SignedURL{ExpiresAt: time.Now().Add(expiry).UTC()}
+	var permissions sas.BlobPermissions
+	switch op {
+	case domain.SignedURLDownload:
+		permissions.Read = true
+		signed.Method = http.MethodGet
+	case domain.SignedURLUpload:
+		permissions.Create = true
+		permissions.Write = true
+		signed.Method = http.MethodPut
+		signed.Headers = map[string]string{"x-ms-blob-type": "BlockBlob"}
+	default:
+		return domain.SignedURL{}, domain.ErrNotSupported
+	}
+
+	// Backdate the start time a little to tolerate clock skew between client and service.
+	start := time.Now().Add(-5 * time.Minute).UTC()
+	blobClient := b.blobService.ServiceClient().NewContainerClient(b.containerName).NewBlobClient(path)
+	u, err := blobClient.GetSASURL(permissions, signed.ExpiresAt, &blob.GetSASURLOptions{StartTime: &start})
+	if err != nil {
+		b.logger.Error("Error signing URL", zap.Error(err), zap.String("path", path))
+		return domain.SignedURL{}, fmt.Errorf("failed to sign URL: %w", err)
+	}
+
+	signed.URL = u
+	return signed, nil
+}
+
+// StageChunk stages a chunk of a resumable upload as an uncommitted block of the target blob.
+func (b *BlobStorageAdapter) StageChunk(ctx context.Context, session domain.UploadSession, index int, chunk []byte) error {
+	blockClient := b.blobService.ServiceClient().NewContainerClient(b.containerName).NewBlockBlobClient(session.Path)
+	_, err := blockClient.StageBlock(ctx, blockID(session.ID, index), streaming.NopCloser(bytes.NewReader(chunk)), nil)
+	if err != nil {
+		b.logger.Error("Error staging block", zap.Error(err), zap.String("path", session.Path), zap.Int("index", index))
+		return fmt.Errorf("failed to stage block: %w", err)
+	}
+	return nil
+}
+
+// CommitChunks commits the staged blocks of a resumable upload, in order, as the blob content.
+func (b *BlobStorageAdapter) CommitChunks(ctx context.Context, session domain.UploadSession) error {
+	ids := make([]string, session.Chunks)
+	for i := range ids {
+		ids[i] = blockID(session.ID, i)
+	}
+
+	blockClient := b.blobService.ServiceClient().NewContainerClient(b.containerName).NewBlockBlobClient(session.Path)
+	_, err := blockClient.CommitBlockList(ctx, ids, &blockblob.CommitBlockListOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &session.ContentType},
+	})
+	if err != nil {
+		b.logger.Error("Error committing blocks", zap.Error(err), zap.String("path", session.Path))
+		return fmt.Errorf("failed to commit blocks: %w", err)
+	}
+
+	b.logger.Info("File uploaded successfully", zap.String("path", session.Path), zap.Int("blocks", session.Chunks))
+	return nil
+}
+
+// AbortChunks abandons a resumable upload. Azure offers no way to drop uncommitted
+// blocks; the service garbage-collects them after a week.
+func (b *BlobStorageAdapter) AbortChunks(ctx context.Context, session domain.UploadSession) error {
+	b.logger.Info("Resumable upload aborted", zap.String("path", session.Path), zap.String("uploadID", session.ID))
+	return nil
+}
+
+// blockID derives the block ID of a chunk. All IDs of a blob must have the same length.
+func blockID(uploadID string, index int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%06d", uploadID, index)))
+}
+
+// deref returns the value behind p, or the zero value when p is nil.
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,378 @@
+package storage
+
+import (
+	"chat-backend-general/config"
+	"chat-backend-general/internal/domain"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LocalStorageAdapter stores files on the local filesystem. It is meant for
+// development and tests, where no cloud credentials are available.
+type LocalStorageAdapter struct {
+	rootDir    string
+	publicURL  string
+	signingKey []byte
+	logger     *zap.Logger
+}
+
+// NewLocalStorageAdapter initializes a new LocalStorageAdapter rooted at cfg.Storage.RootDir.
+func NewLocalStorageAdapter(cfg *config.Config, logger *zap.Logger) (*LocalStorageAdapter, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	if cfg.Storage.RootDir == "" {
+		return nil, errors.New("storage root directory is empty")
+	}
+
+	rootDir, err := filepath.Abs(cfg.Storage.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage root directory: %w", err)
+	}
+	if err := os.MkdirAll(rootDir, 0o755); err != nil {
+		logger.Error("Failed to create storage root directory", zap.Error(err), zap.String("rootDir", rootDir))
+		return nil, fmt.Errorf("failed to create storage root directory: %w", err)
+	}
+
+	signingKey := []byte(cfg.Storage.SigningKey)
+	if len(signingKey) == 0 {
+		logger.Warn("STORAGE_SIGNING_KEY is not set, signed URLs will not survive a restart")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+	}
+
+	return &LocalStorageAdapter{
+		rootDir:    rootDir,
+		publicURL:  strings.TrimSuffix(cfg.Storage.PublicUrl, "/"),
+		signingKey: signingKey,
+		logger:     logger,
+	}, nil
+}
+
+// SaveFile writes a file below the root directory, keeping the layout of file.Path.
+func (l *LocalStorageAdapter) SaveFile(ctx context.Context, file domain.UploadedFile) error {
+	if file.File == nil {
+		l.logger.Error("file is nil")
+		return errors.New("file is nil")
+	}
+
+	target, err := l.resolve(file.Path)
+	if err != nil {
+		l.logger.Error("Invalid file path", zap.Error(err), zap.String("path", file.Path))
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never observe a partial upload.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: file.File}); err != nil {
+		tmp.Close()
+		l.logger.Error("Error uploading file", zap.Error(err), zap.String("path", file.Path))
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	l.logger.Info("File uploaded successfully", zap.String("path", file.Path))
+	return nil
+}
+
+// StatFile returns the metadata of a previously uploaded file.
+func (l *LocalStorageAdapter) StatFile(ctx context.Context, path string) (domain.FileInfo, error) {
+	target, err := l.resolve(path)
+	if err != nil {
+		return domain.FileInfo{}, err
+	}
+
+	stat, err := os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return domain.FileInfo{}, domain.ErrFileNotFound
+	}
+	if err != nil {
+		return domain.FileInfo{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	return l.fileInfo(path, stat), nil
+}
+
+// GetFile opens a previously uploaded file, or a range of it, for reading. The caller must close the returned content.
+func (l *LocalStorageAdapter) GetFile(ctx context.Context, path string, rng *domain.ByteRange) (domain.StoredFile, error) {
+	target, err := l.resolve(path)
+	if err != nil {
+		return domain.StoredFile{}, err
+	}
+
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return domain.StoredFile{}, domain.ErrFileNotFound
+	}
+	if err != nil {
+		return domain.StoredFile{}, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return domain.StoredFile{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		f.Close()
+		return domain.StoredFile{}, domain.ErrFileNotFound
+	}
+
+	var content io.ReadCloser = f
+	if rng != nil {
+		content = sectionReadCloser{SectionReader: io.NewSectionReader(f, rng.Offset, rng.Length), Closer: f}
+	}
+
+	return domain.StoredFile{FileInfo: l.fileInfo(path, stat), Content: content}, nil
+}
+
+// ListFiles lists the files whose path starts with prefix.
+func (l *LocalStorageAdapter) ListFiles(ctx context.Context, prefix string) ([]domain.FileInfo, error) {
+	// Walk from the deepest directory named by the prefix and filter the rest by name.
+	dir := l.rootDir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		resolved, err := l.resolve(prefix[:i])
+		if err != nil {
+			return nil, err
+		}
+		dir = resolved
+	}
+
+	files := []domain.FileInfo{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.rootDir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, l.fileInfo(key, stat))
+		return nil
+	})
+	if err != nil {
+		l.logger.Error("Error listing files", zap.Error(err), zap.String("prefix", prefix))
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return files, nil
+}
+
+// DeleteFile removes a file.
+func (l *LocalStorageAdapter) DeleteFile(ctx context.Context, path string) error {
+	target, err := l.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return domain.ErrFileNotFound
+	}
+	if err != nil {
+		l.logger.Error("Error deleting file", zap.Error(err), zap.String("path", path))
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	l.logger.Info("File deleted successfully", zap.String("path", path))
+	return nil
+}
+
+// StageChunk writes a chunk of a resumable upload to the upload's staging directory.
+func (l *LocalStorageAdapter) StageChunk(ctx context.Context, session domain.UploadSession, index int, chunk []byte) error {
+	dir := l.stagingDir(session.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%06d", index)), chunk, 0o644); err != nil {
+		l.logger.Error("Error staging chunk", zap.Error(err), zap.String("path", session.Path), zap.Int("index", index))
+		return fmt.Errorf("failed to stage chunk: %w", err)
+	}
+	return nil
+}
+
+// CommitChunks concatenates the staged chunks into session.Path and removes them.
+func (l *LocalStorageAdapter) CommitChunks(ctx context.Context, session domain.UploadSession) error {
+	dir := l.stagingDir(session.ID)
+	readers := make([]io.Reader, 0, session.Chunks)
+	for i := 0; i < session.Chunks; i++ {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%06d", i)))
+		if err != nil {
+			return fmt.Errorf("failed to open staged chunk: %w", err)
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+
+	file := domain.UploadedFile{
+		Name:        session.Name,
+		ContentType: session.ContentType,
+		File:        io.MultiReader(readers...),
+		Size:        session.Length,
+		Path:        session.Path,
+	}
+	if err := l.SaveFile(ctx, file); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// AbortChunks removes the staged chunks of a resumable upload.
+func (l *LocalStorageAdapter) AbortChunks(ctx context.Context, session domain.UploadSession) error {
+	return os.RemoveAll(l.stagingDir(session.ID))
+}
+
+// stagingDir holds the chunks of a resumable upload. Dot directories are hidden from ListFiles.
+func (l *LocalStorageAdapter) stagingDir(uploadID string) string {
+	return filepath.Join(l.rootDir, ".uploads", filepath.Base(uploadID))
+}
+
+// LocalSignedURLPrefix is the route under which this service serves local signed URLs.
+const LocalSignedURLPrefix = "/storage/"
+
+// SignURL returns an HMAC-signed URL pointing at this service's local storage routes.
+// The size of an upload is part of the signature.
+func (l *LocalStorageAdapter) SignURL(ctx context.Context, path string, op domain.SignedURLOperation, expiry time.Duration, size int64) (domain.SignedURL, error) {
+	if _, err := l.resolve(path); err != nil {
+		return domain.SignedURL{}, err
+	}
+
+	signed := domain.SignedURL{ExpiresAt: time.Now().Add(expiry).UTC().Truncate(time.Second)}
+	switch op {
+	case domain.SignedURLDownload:
+		signed.Method = http.MethodGet
+		size = 0
+	case domain.SignedURLUpload:
+		signed.Method = http.MethodPut
+	default:
+		return domain.SignedURL{}, domain.ErrNotSupported
+	}
+
+	expires := signed.ExpiresAt.Unix()
+	query := url.Values{}
+	query.Set("op", string(op))
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	if op == domain.SignedURLUpload {
+		query.Set("size", strconv.FormatInt(size, 10))
+	}
+	query.Set("sig", l.signature(path, op, expires, size))
+	signed.URL = l.publicURL + LocalSignedURLPrefix + (&url.URL{Path: path}).EscapedPath() + "?" + query.Encode()
+	return signed, nil
+}
+
+// VerifySignedURL checks a signature produced by SignURL and that it has not expired.
+func (l *LocalStorageAdapter) VerifySignedURL(path string, op domain.SignedURLOperation, expires, size int64, signature string) error {
+	if time.Now().Unix() > expires {
+		return domain.ErrInvalidSignature
+	}
+	expected := l.signature(path, op, expires, size)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return domain.ErrInvalidSignature
+	}
+	return nil
+}
+
+func (l *LocalStorageAdapter) signature(path string, op domain.SignedURLOperation, expires, size int64) string {
+	mac := hmac.New(sha256.New, l.signingKey)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%d", op, path, expires, size)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (l *LocalStorageAdapter) fileInfo(path string, stat fs.FileInfo) domain.FileInfo {
+	return domain.FileInfo{
+		Name:         stat.Name(),
+		Path:         path,
+		ContentType:  mime.TypeByExtension(filepath.Ext(stat.Name())),
+		Size:         stat.Size(),
+		ETag:         fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), stat.Size()),
+		LastModified: stat.ModTime(),
+	}
+}
+
+// resolve maps a storage path to a location inside the root directory.
+func (l *LocalStorageAdapter) resolve(path string) (string, error) {
+	if path == "" {
+		return "", domain.ErrInvalidPath
+	}
+
+	target := filepath.Join(l.rootDir, filepath.FromSlash(path))
+	if !strings.HasPrefix(target, l.rootDir+string(filepath.Separator)) {
+		return "", domain.ErrInvalidPath
+	}
+	return target, nil
+}
+
+// sectionReadCloser reads a section of a file and closes the file when done.
+type sectionReadCloser struct {
+	*io.SectionReader
+	io.Closer
+}
+
+// contextReader stops a copy once its context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-backend-general/config"
+	"chat-backend-general/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorageAdapter {
+	t.Helper()
+	cfg := &config.Config{Storage: config.StorageProvider{Provider: ProviderLocal, RootDir: t.TempDir()}}
+	adapter, err := NewLocalStorageAdapter(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewLocalStorageAdapter() error = %v", err)
+	}
+	return adapter
+}
+
+func TestLocalStorageAdapter_SaveAndGetFile(t *testing.T) {
+	adapter := newTestLocalStorage(t)
+	ctx := context.Background()
+
+	file := domain.UploadedFile{
+		Name:        "notes.txt",
+		ContentType: "text/plain",
+		File:        strings.NewReader("hello world"),
+		Size:        11,
+		Path:        "alice/chat-1/notes.txt",
+	}
+	if err := adapter.SaveFile(ctx, file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	stored, err := adapter.GetFile(ctx, file.Path, nil)
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	defer stored.Content.Close()
+
+	if stored.Size != file.Size || stored.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("GetFile() info = %+v, want size %d and text/plain", stored.FileInfo, file.Size)
+	}
+
+	got, err := io.ReadAll(stored.Content)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("GetFile() content = %q, want %q", got, "hello world")
+	}
+
+	ranged, err := adapter.GetFile(ctx, file.Path, &domain.ByteRange{Offset: 6, Length: 5})
+	if err != nil {
+		t.Fatalf("GetFile() with range error = %v", err)
+	}
+	defer ranged.Content.Close()
+
+	got, err = io.ReadAll(ranged.Content)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != "world" || ranged.Size != file.Size {
+		t.Errorf("GetFile() with range = %q (size %d), want %q (size %d)", got, ranged.Size, "world", file.Size)
+	}
+}
+
+func TestLocalStorageAdapter_InvalidPaths(t *testing.T) {
+	adapter := newTestLocalStorage(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected error
+	}{
+		{name: "empty path", path: "", expected: domain.ErrInvalidPath},
+		{name: "parent traversal", path: "../outside.txt", expected: domain.ErrInvalidPath},
+		{name: "nested traversal", path: "alice/../../outside.txt", expected: domain.ErrInvalidPath},
+		{name: "missing file", path: "alice/chat-1/missing.txt", expected: domain.ErrFileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := adapter.GetFile(ctx, tt.path, nil)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("GetFile(%q) error = %v, want %v", tt.path, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLocalStorageAdapter_ListAndDeleteFiles(t *testing.T) {
+	adapter := newTestLocalStorage(t)
+	ctx := context.Background()
+
+	for _, path := range []string{"alice/chat-1/a.txt", "alice/chat-1/b.txt", "alice/chat-2/c.txt", "bob/chat-1/d.txt"} {
+		file := domain.UploadedFile{File: strings.NewReader(path), Path: path}
+		if err := adapter.SaveFile(ctx, file); err != nil {
+			t.Fatalf("SaveFile(%q) error = %v", path, err)
+		}
+	}
+
+	files, err := adapter.ListFiles(ctx, "alice/chat-1/")
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "alice/chat-1/a.txt" || files[1].Path != "alice/chat-1/b.txt" {
+		t.Errorf("ListFiles(%q) = %+v, want a.txt and b.txt", "alice/chat-1/", files)
+	}
+
+	if err := adapter.DeleteFile(ctx, "alice/chat-1/a.txt"); err != nil {
+		t.Fatalf("DeleteFile() error = %v", err)
+	}
+	if err := adapter.DeleteFile(ctx, "alice/chat-1/a.txt"); !errors.Is(err, domain.ErrFileNotFound) {
+		t.Errorf("DeleteFile() on deleted file error = %v, want %v", err, domain.ErrFileNotFound)
+	}
+
+	files, err = adapter.ListFiles(ctx, "alice/")
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("ListFiles(%q) returned %d files, want 2", "alice/", len(files))
+	}
+}
+
+func TestLocalStorageAdapter_SignedURLs(t *testing.T) {
+	adapter := newTestLocalStorage(t)
+	ctx := context.Background()
+
+	signed, err := adapter.SignURL(ctx, "alice/chat-1/report.pdf", domain.SignedURLDownload, time.Minute, 0)
+	if err != nil {
+		t.Fatalf("SignURL() error = %v", err)
+	}
+	u, err := url.Parse(signed.URL)
+	if err != nil {
+		t.Fatalf("SignURL() returned unparsable URL %q: %v", signed.URL, err)
+	}
+	if u.Path != LocalSignedURLPrefix+"alice/chat-1/report.pdf" || signed.Method != http.MethodGet {
+		t.Errorf("SignURL() = %s %s, want GET on %s", signed.Method, u.Path, LocalSignedURLPrefix+"alice/chat-1/report.pdf")
+	}
+
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	sig := u.Query().Get("sig")
+
+	tests := []struct {
+		name     string
+		path     string
+		op       domain.SignedURLOperation
+		expires  int64
+		size     int64
+		expected error
+	}{
+		{name: "valid", path: "alice/chat-1/report.pdf", op: domain.SignedURLDownload, expires: expires, expected: nil},
+		{name: "other path", path: "bob/chat-1/report.pdf", op: domain.SignedURLDownload, expires: expires, expected: domain.ErrInvalidSignature},
+		{name: "other operation", path: "alice/chat-1/report.pdf", op: domain.SignedURLUpload, expires: expires, expected: domain.ErrInvalidSignature},
+		{name: "other size", path: "alice/chat-1/report.pdf", op: domain.SignedURLDownload, expires: expires, size: 1 << 20, expected: domain.ErrInvalidSignature},
+		{name: "extended expiry", path: "alice/chat-1/report.pdf", op: domain.SignedURLDownload, expires: expires + 3600, expected: domain.ErrInvalidSignature},
+		{name: "expired", path: "alice/chat-1/report.pdf", op: domain.SignedURLDownload, expires: time.Now().Add(-time.Minute).Unix(), expected: domain.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := adapter.VerifySignedURL(tt.path, tt.op, tt.expires, tt.size, sig)
+			if err != tt.expected {
+				t.Errorf("VerifySignedURL(%q, %q, %d, %d) = %v, want %v", tt.path, tt.op, tt.expires, tt.size, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"chat-backend-general/config"
+	"chat-backend-general/internal/domain"
+	infraStorage "chat-backend-general/internal/infra/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	pathpkg "path"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+// S3StorageAdapter is an adapter for AWS S3 and S3-compatible object stores such as MinIO.
+type S3StorageAdapter struct {
+	bucketName string
+	client     *minio.Client
+	logger     *zap.Logger
+}
+
+// NewS3StorageAdapter initializes a new S3StorageAdapter.
+func NewS3StorageAdapter(cfg *config.Config, logger *zap.Logger) (*S3StorageAdapter, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	if cfg.Storage.Config.BucketName == "" {
+		return nil, errors.New("storage bucket name is empty")
+	}
+
+	client, err := infraStorage.NewS3Client(cfg.Storage.Config)
+	if err != nil {
+		logger.Error("Failed to create S3 client", zap.Error(err))
+		return nil, err
+	}
+
+	return &S3StorageAdapter{
+		bucketName: cfg.Storage.Config.BucketName,
+		client:     client,
+		logger:     logger,
+	}, nil
+}
+
+// SaveFile uploads a file to the configured S3 bucket.
+func (s *S3StorageAdapter) SaveFile(ctx context.Context, file domain.UploadedFile) error {
+	if s.client == nil {
+		s.logger.Error("s3 client is nil")
+		return errors.New("s3 client is nil")
+	}
+	if file.File == nil {
+		s.logger.Error("file is nil")
+		return errors.New("file is nil")
+	}
+
+	// A size of -1 makes the client fall back to a streaming multipart upload.
+	size := file.Size
+	if size <= 0 {
+		size = -1
+	}
+
+	opts := minio.PutObjectOptions{ContentType: file.ContentType}
+	_, err := s.client.PutObject(ctx, s.bucketName, file.Path, file.File, size, opts)
+	if err != nil {
+		s.logger.Error("Error uploading file", zap.Error(err), zap.String("path", file.Path))
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	s.logger.Info("File uploaded successfully", zap.String("path", file.Path))
+	return nil
+}
+
+// RecordChecksum stores the SHA-256 checksum of an object in its user metadata. S3
+// metadata cannot be changed in place, so the object is copied onto itself.
+func (s *S3StorageAdapter) RecordChecksum(ctx context.Context, path, checksum string) error {
+	stat, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{})
+	if err != nil {
+		return s.objectError("Error reading file metadata", err, path)
+	}
+
+	metadata := map[string]string{"Content-Type": stat.ContentType, checksumMetadataKey: checksum}
+	for key, value := range stat.UserMetadata {
+		if _, ok := metadata[key]; !ok {
+			metadata[key] = value
+		}
+	}
+	dst := minio.CopyDestOptions{Bucket: s.bucketName, Object: path, UserMetadata: metadata, ReplaceMetadata: true}
+	src := minio.CopySrcOptions{Bucket: s.bucketName, Object: path, MatchETag: stat.ETag}
+	if _, err := s.client.CopyObject(ctx, dst, src); err != nil {
+		return s.objectError("Error recording file checksum", err, path)
+	}
+	return nil
+}
+
+// StatFile returns the metadata of an object without downloading it.
+func (s *S3StorageAdapter) StatFile(ctx context.Context, path string) (domain.FileInfo, error) {
+	stat, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{})
+	if err != nil {
+		return domain.FileInfo{}, s.objectError("Error reading file metadata", err, path)
+	}
+	return objectInfo(stat), nil
+}
+
+// GetFile opens an object, or a range of it, for streaming. The caller must close the returned content.
+func (s *S3StorageAdapter) GetFile(ctx context.Context, path string, rng *domain.ByteRange) (domain.StoredFile, error) {
+	opts := minio.GetObjectOptions{}
+	if rng != nil {
+		if err := opts.SetRange(rng.Offset, rng.Offset+rng.Length-1); err != nil {
+			return domain.StoredFile{}, fmt.Errorf("invalid range: %w", err)
+		}
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucketName, path, opts)
+	if err != nil {
+		return domain.StoredFile{}, s.objectError("Error downloading file", err, path)
+	}
+
+	// GetObject is lazy; Stat issues the request and surfaces missing keys.
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return domain.StoredFile{}, s.objectError("Error downloading file", err, path)
+	}
+
+	return domain.StoredFile{FileInfo: objectInfo(stat), Content: obj}, nil
+}
+
+// ListFiles lists the objects whose key starts with prefix.
+func (s *S3StorageAdapter) ListFiles(ctx context.Context, prefix string) ([]domain.FileInfo, error) {
+	files := []domain.FileInfo{}
+	for obj := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			s.logger.Error("Error listing files", zap.Error(obj.Err), zap.String("prefix", prefix))
+			return nil, fmt.Errorf("failed to list files: %w", obj.Err)
+		}
+		files = append(files, objectInfo(obj))
+	}
+	return files, nil
+}
+
+// DeleteFile removes an object.
+func (s *S3StorageAdapter) DeleteFile(ctx context.Context, path string) error {
+	// S3 deletes are idempotent, so check for the object first to report missing files.
+	if _, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{}); err != nil {
+		return s.objectError("Error deleting file", err, path)
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucketName, path, minio.RemoveObjectOptions{}); err != nil {
+		s.logger.Error("Error deleting file", zap.Error(err), zap.String("path", path))
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	s.logger.Info("File deleted successfully", zap.String("path", path))
+	return nil
+}
+
+// SignURL returns a presigned URL for downloading or uploading an object directly. The
+// Content-Length of an upload is signed, so S3 refuses content of another size.
+func (s *S3StorageAdapter) SignURL(ctx context.Context, path string, op domain.SignedURLOperation, expiry time.Duration, size int64) (domain.SignedURL, error) {
+	signed := domain.SignedURL{ExpiresAt: time.Now().Add(expiry).UTC()}
+
+	var u *url.URL
+	var err error
+	switch op {
+	case domain.SignedURLDownload:
+		signed.Method = http.MethodGet
+		u, err = s.client.PresignedGetObject(ctx, s.bucketName, path, expiry, nil)
+	case domain.SignedURLUpload:
+		signed.Method = http.MethodPut
+		length := strconv.FormatInt(size, 10)
+		signed.Headers = map[string]string{"Content-Length": length}
+		u, err = s.client.PresignHeader(ctx, http.MethodPut, s.bucketName, path, expiry, nil, http.Header{"Content-Length": {length}})
+	default:
+		return domain.SignedURL{}, domain.ErrNotSupported
+	}
+	if err != nil {
+		s.logger.Error("Error signing URL", zap.Error(err), zap.String("path", path))
+		return domain.SignedURL{}, fmt.Errorf("failed to sign URL: %w", err)
+	}
+
+	signed.URL = u.String()
+	return signed, nil
+}
+
+// objectError maps missing keys to domain.ErrFileNotFound and logs anything else.
+func (s *S3StorageAdapter) objectError(msg string, err error, path string) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return domain.ErrFileNotFound
+	}
+	s.logger.Error(msg, zap.Error(err), zap.String("path", path))
+	return fmt.Errorf("s3 request failed: %w", err)
+}
+
+func objectInfo(obj minio.ObjectInfo) domain.FileInfo {
+	return domain.FileInfo{
+		Name:         pathpkg.Base(obj.Key),
+		Path:         obj.Key,
+		ContentType:  obj.ContentType,
+		Size:         obj.Size,
+		ETag:         obj.ETag,
+		LastModified: obj.LastModified,
+	}
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"chat-backend-general/config"
+	"chat-backend-general/internal/domain"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Supported values for STORAGE_PROVIDER.
+const (
+	ProviderAzureBlob = "azure"
+	ProviderS3        = "s3"
+	ProviderMinIO     = "minio"
+	ProviderLocal     = "local"
+)
+
+// checksumMetadataKey is the metadata key holding the SHA-256 checksum of stored files.
+const checksumMetadataKey = "sha256"
+
+// NewFileRepository returns the storage adapter selected by cfg.Storage.Provider.
+func NewFileRepository(cfg *config.Config, logger *zap.Logger) (domain.FileRepository, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+
+	switch strings.ToLower(cfg.Storage.Provider) {
+	case ProviderAzureBlob, "azureblob", "azure-blob":
+		return NewBlobStorageAdapter(cfg, logger)
+	case ProviderS3, ProviderMinIO:
+		return NewS3StorageAdapter(cfg, logger)
+	case ProviderLocal:
+		return NewLocalStorageAdapter(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unsupported storage provider %q", cfg.Storage.Provider)
+	}
+}
@@ -0,0 +1,226 @@
+// internal/adaptors/validation/file_archive_validator.go
+package validation
+
+import (
+	"archive/zip"
+	"bytes"
+	"chat-backend-general/internal/domain"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ratioGrace is the uncompressed size up to which entries are not held to MaxRatio, as
+// small, repetitive XML parts legitimately compress very well.
+const ratioGrace = 1 << 20
+
+// zipMagic are the signatures of a ZIP file starting with an entry and of an empty one.
+var zipMagic = [][]byte{[]byte("PK\x03\x04"), []byte("PK\x05\x06")}
+
+// maxEmbeddingDepth is how many levels of documents embedded in documents are checked;
+// deeper ones are rejected as nested archives.
+const maxEmbeddingDepth = 2
+
+// embeddingPrefixes are the folders in which Office Open XML documents keep embedded documents.
+var embeddingPrefixes = []string{"word/embeddings/", "ppt/embeddings/", "xl/embeddings/"}
+
+// archiveMagic are the signatures of archive formats that are not accepted inside a document.
+var archiveMagic = [][]byte{
+	[]byte("PK\x03\x04"),         // ZIP, including embedded Office documents
+	[]byte("Rar!\x1a\x07"),       // RAR
+	[]byte("7z\xbc\xaf\x27\x1c"), // 7-Zip
+	[]byte("\x1f\x8b"),           // gzip
+	[]byte("BZh"),                // bzip2
+	[]byte("\xfd7zXZ\x00"),       // xz
+	[]byte("MSCF"),               // Cabinet
+	[]byte("\x28\xb5\x2f\xfd"),   // zstd
+}
+
+// FileArchiveValidator inspects ZIP based documents, such as .docx, .xlsx and .odt files,
+// for zip bombs, nested archives and macros before they are stored or processed.
+type FileArchiveValidator struct {
+	MaxEntries          int     // Zero means unlimited
+	MaxUncompressedSize int64   // Bytes of all entries together; zero means unlimited
+	MaxRatio            float64 // Uncompressed to compressed size of an entry; zero means unlimited
+	AllowMacros         bool
+}
+
+// Validate checks ZIP content against the archive limits. Sizes are taken from the
+// archive's central directory, which decompressors such as archive/zip enforce while
+// reading. Other content is accepted, and so is content without random access, such as
+// the first chunk of a resumable upload; those are validated once assembled.
+func (v *FileArchiveValidator) Validate(file domain.UploadedFile) error {
+	content, ok := file.File.(io.ReaderAt)
+	if !ok {
+		return nil
+	}
+	head := make([]byte, len(zipMagic[0]))
+	if n, _ := content.ReadAt(head, 0); !hasPrefix(head[:n], zipMagic) {
+		return nil
+	}
+
+	archive, err := zip.NewReader(content, file.Size)
+	if err != nil {
+		return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeInvalidFile, "content",
+			fmt.Sprintf("file is not a readable archive: %v", err), nil, nil)
+	}
+
+	return v.validateArchive(archive, "", &archiveUsage{}, 0)
+}
+
+// archiveUsage tallies the entries of a document and of the documents embedded in it,
+// which count towards the same limits.
+type archiveUsage struct {
+	entries int
+	size    uint64
+}
+
+// validateArchive checks the entries of archive, whose names are reported under prefix,
+// and recurses into the documents embedded in it at depth+1.
+func (v *FileArchiveValidator) validateArchive(archive *zip.Reader, prefix string, usage *archiveUsage, depth int) error {
+	usage.entries += len(archive.File)
+	if v.MaxEntries > 0 && usage.entries > v.MaxEntries {
+		return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeArchiveTooManyFiles, "content",
+			fmt.Sprintf("archive has %d entries, more than the limit of %d", usage.entries, v.MaxEntries), v.MaxEntries, usage.entries)
+	}
+
+	for _, entry := range archive.File {
+		name := prefix + entry.Name
+		usage.size += entry.UncompressedSize64
+		if v.MaxUncompressedSize > 0 && usage.size > uint64(v.MaxUncompressedSize) {
+			return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeArchiveTooLarge, "content",
+				fmt.Sprintf("archive expands to more than %d bytes", v.MaxUncompressedSize), v.MaxUncompressedSize, usage.size)
+		}
+		if ratio, ok := v.ratioExceeded(entry); ok {
+			return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeArchiveRatio, "content",
+				fmt.Sprintf("archive entry %q is compressed %.0f:1, more than the limit of %.0f:1", name, ratio, v.MaxRatio), v.MaxRatio, ratio)
+		}
+		if !v.AllowMacros && isMacro(entry.Name) {
+			return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeMacrosNotAllowed, "content",
+				fmt.Sprintf("document contains macros (%s)", name), nil, name)
+		}
+	}
+
+	// Checked last, as it decompresses the start of every entry.
+	for _, entry := range archive.File {
+		name := prefix + entry.Name
+		nested, err := isArchive(entry)
+		if err != nil {
+			return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeInvalidFile, "content",
+				fmt.Sprintf("archive entry %q cannot be read: %v", name, err), nil, name)
+		}
+		if !nested {
+			continue
+		}
+
+		// Office documents embed the documents behind charts and OLE objects, which are
+		// ZIP archives themselves; those are checked like the document. Other archives are not accepted.
+		var embedded *zip.Reader
+		if depth < maxEmbeddingDepth && isEmbedding(entry.Name) {
+			if embedded, err = openEmbedded(entry); err != nil {
+				return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeInvalidFile, "content",
+					fmt.Sprintf("embedded document %q is not a readable archive: %v", name, err), nil, name)
+			}
+		}
+		if embedded == nil {
+			return domain.NewValidationError(domain.ErrUnsafeArchive, domain.ValidationCodeArchiveNested, "content",
+				fmt.Sprintf("archive entry %q is an archive itself", name), nil, name)
+		}
+		if err := v.validateArchive(embedded, name+"/", usage, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ratioExceeded returns the compression ratio of entry and whether it is over MaxRatio.
+func (v *FileArchiveValidator) ratioExceeded(entry *zip.File) (float64, bool) {
+	if v.MaxRatio <= 0 || entry.UncompressedSize64 <= ratioGrace {
+		return 0, false
+	}
+	if entry.CompressedSize64 == 0 {
+		return float64(entry.UncompressedSize64), true
+	}
+	ratio := float64(entry.UncompressedSize64) / float64(entry.CompressedSize64)
+	return ratio, ratio > v.MaxRatio
+}
+
+// NewFileArchiveValidator creates a new FileArchiveValidator.
+func NewFileArchiveValidator(maxEntries int, maxUncompressedSize int64, maxRatio float64, allowMacros bool) *FileArchiveValidator {
+	return &FileArchiveValidator{
+		MaxEntries:          maxEntries,
+		MaxUncompressedSize: maxUncompressedSize,
+		MaxRatio:            maxRatio,
+		AllowMacros:         allowMacros,
+	}
+}
+
+// isMacro reports whether an entry name holds macros: VBA projects of Office documents
+// and the Basic and script libraries of OpenDocument files.
+func isMacro(name string) bool {
+	lower := strings.ToLower(name)
+	switch path.Base(lower) {
+	case "vbaproject.bin", "vbadata.xml":
+		return true
+	}
+	return strings.HasPrefix(lower, "basic/") || strings.HasPrefix(lower, "scripts/")
+}
+
+// isEmbedding reports whether an entry name is in one of embeddingPrefixes.
+func isEmbedding(name string) bool {
+	lower := strings.ToLower(name)
+	for _, prefix := range embeddingPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openEmbedded reads entry, an embedded document, and opens it as a ZIP archive. It
+// returns nil when entry is another kind of archive. The entry's size has been counted
+// against MaxUncompressedSize, and archive/zip stops reading beyond it.
+func openEmbedded(entry *zip.File) (*zip.Reader, error) {
+	r, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPrefix(content, zipMagic) {
+		return nil, nil
+	}
+	return zip.NewReader(bytes.NewReader(content), int64(len(content)))
+}
+
+// isArchive reports whether entry's content starts with an archive signature.
+func isArchive(entry *zip.File) (bool, error) {
+	if entry.FileInfo().IsDir() {
+		return false, nil
+	}
+	r, err := entry.Open()
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	head := make([]byte, 8)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return hasPrefix(head[:n], archiveMagic), nil
+}
+
+func hasPrefix(head []byte, prefixes [][]byte) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(head, prefix) {
+			return true
+		}
+	}
+	return false
+}
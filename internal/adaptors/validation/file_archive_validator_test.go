@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+// zipEntry is a file written to a test archive.
+type zipEntry struct {
+	name    string
+	content []byte
+}
+
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		f, err := w.Create(entry.name)
+		if err != nil {
+			t.Fatalf("zip.Writer.Create(%q) error = %v", entry.name, err)
+		}
+		f.Write(entry.content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("zip.Writer.Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// incompressible returns n random bytes, which deflate cannot shrink.
+func incompressible(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(1)).Read(b)
+	return b
+}
+
+func TestFileArchiveValidator_Validate(t *testing.T) {
+	validator := NewFileArchiveValidator(5, 4<<20, 100, false)
+
+	document := []zipEntry{
+		{name: "[Content_Types].xml", content: []byte(`<?xml version="1.0"?><Types/>`)},
+		{name: "word/document.xml", content: []byte(`<?xml version="1.0"?><w:document/>`)},
+	}
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte("payload"))
+	gz.Close()
+
+	tests := []struct {
+		name     string
+		content  []byte
+		expected string // ValidationError code, "" for valid files
+	}{
+		{name: "document", content: buildZip(t, document...)},
+		{name: "not an archive", content: []byte("%PDF-1.7\n")},
+		{name: "too many entries", content: buildZip(t, zipEntry{name: "a"}, zipEntry{name: "b"}, zipEntry{name: "c"}, zipEntry{name: "d"}, zipEntry{name: "e"}, zipEntry{name: "f"}),
+			expected: domain.ValidationCodeArchiveTooManyFiles},
+		{name: "too large", content: buildZip(t, zipEntry{name: "a.bin", content: incompressible(3 << 20)}, zipEntry{name: "b.bin", content: incompressible(3 << 20)}),
+			expected: domain.ValidationCodeArchiveTooLarge},
+		{name: "compression ratio", content: buildZip(t, zipEntry{name: "word/document.xml", content: make([]byte, 2<<20)}),
+			expected: domain.ValidationCodeArchiveRatio},
+		{name: "small entries may compress well", content: buildZip(t, zipEntry{name: "word/styles.xml", content: make([]byte, 512<<10)})},
+		{name: "office macros", content: buildZip(t, append(document, zipEntry{name: "word/vbaProject.bin", content: []byte("\xd0\xcf\x11\xe0")})...),
+			expected: domain.ValidationCodeMacrosNotAllowed},
+		{name: "opendocument macros", content: buildZip(t, zipEntry{name: "content.xml"}, zipEntry{name: "Basic/Standard/Module1.xml", content: []byte("<script/>")}),
+			expected: domain.ValidationCodeMacrosNotAllowed},
+		{name: "nested zip", content: buildZip(t, append(document, zipEntry{name: "word/media/data.zip", content: buildZip(t, document...)})...),
+			expected: domain.ValidationCodeArchiveNested},
+		{name: "embedded document", content: buildZip(t, append(document, zipEntry{name: "word/embeddings/data.xlsx", content: buildZip(t, zipEntry{name: "xl/workbook.xml"})})...)},
+		{name: "embedded document with macros", content: buildZip(t, append(document, zipEntry{name: "ppt/embeddings/data.xlsm", content: buildZip(t, zipEntry{name: "xl/vbaProject.bin", content: []byte("\xd0\xcf\x11\xe0")})})...),
+			expected: domain.ValidationCodeMacrosNotAllowed},
+		{name: "embedded document over the entry limit", content: buildZip(t, append(document, zipEntry{name: "word/embeddings/data.xlsx", content: buildZip(t, append(document, zipEntry{name: "xl/workbook.xml"})...)})...),
+			expected: domain.ValidationCodeArchiveTooManyFiles},
+		{name: "embedded document over the size limit", content: buildZip(t, zipEntry{name: "a.bin", content: incompressible(3 << 20)}, zipEntry{name: "word/embeddings/data.xlsx", content: buildZip(t, zipEntry{name: "b.bin", content: incompressible(1 << 20)})}),
+			expected: domain.ValidationCodeArchiveTooLarge},
+		{name: "embedded gzip", content: buildZip(t, append(document, zipEntry{name: "word/embeddings/data.bin", content: gzipped.Bytes()})...),
+			expected: domain.ValidationCodeArchiveNested},
+		{name: "nested gzip", content: buildZip(t, append(document, zipEntry{name: "word/media/image1.png", content: gzipped.Bytes()})...),
+			expected: domain.ValidationCodeArchiveNested},
+		{name: "truncated archive", content: buildZip(t, document...)[:40], expected: domain.ValidationCodeInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(domain.UploadedFile{Name: "letter.docx", File: bytes.NewReader(tt.content), Size: int64(len(tt.content))})
+			var validationErr *domain.ValidationError
+			if errors.As(err, &validationErr) != (tt.expected != "") || (validationErr != nil && validationErr.Code != tt.expected) {
+				t.Errorf("FileArchiveValidator.Validate() = %v, want code %q", err, tt.expected)
+			}
+			if tt.expected != "" && !errors.Is(err, domain.ErrUnsafeArchive) {
+				t.Errorf("FileArchiveValidator.Validate() = %v, want %v", err, domain.ErrUnsafeArchive)
+			}
+		})
+	}
+}
+
+func TestFileArchiveValidator_AllowMacros(t *testing.T) {
+	content := buildZip(t, zipEntry{name: "xl/workbook.xml"}, zipEntry{name: "xl/vbaProject.bin", content: []byte("\xd0\xcf\x11\xe0")})
+	validator := NewFileArchiveValidator(0, 0, 0, true)
+	if err := validator.Validate(domain.UploadedFile{Name: "book.xlsm", File: bytes.NewReader(content), Size: int64(len(content))}); err != nil {
+		t.Errorf("FileArchiveValidator.Validate() = %v, want nil when macros are allowed", err)
+	}
+}
+
+func TestFileArchiveValidator_SkipsStreams(t *testing.T) {
+	content := buildZip(t, zipEntry{name: "word/vbaProject.bin"})
+	file := domain.UploadedFile{Name: "letter.docx", File: bufio.NewReader(bytes.NewReader(content)), Size: int64(len(content))}
+	if err := NewFileArchiveValidator(0, 0, 0, false).Validate(file); err != nil {
+		t.Errorf("FileArchiveValidator.Validate() = %v, want nil for content without random access", err)
+	}
+}
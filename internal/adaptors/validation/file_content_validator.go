@@ -0,0 +1,163 @@
+// internal/adaptors/validation/file_content_validator.go
+package validation
+
+import (
+	"chat-backend-general/internal/domain"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// sniffLength is how many leading bytes are inspected, matching mimetype's default read limit.
+const sniffLength = 3072
+
+// extensionTypes maps the extensions of supported documents to their MIME type. Go's
+// builtin table lacks most office formats, so it is only used as a fallback.
+var extensionTypes = map[string]string{
+	".doc":      "application/msword",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".ppt":      "application/vnd.ms-powerpoint",
+	".pptx":     "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".xls":      "application/vnd.ms-excel",
+	".xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".odp":      "application/vnd.oasis.opendocument.presentation",
+	".ods":      "application/vnd.oasis.opendocument.spreadsheet",
+	".txt":      "text/plain",
+	".pdf":      "application/pdf",
+	".csv":      "text/csv",
+	".tsv":      "text/tab-separated-values",
+	".htm":      "text/html",
+	".html":     "text/html",
+	".xlf":      "application/xliff+xml",
+	".xliff":    "application/xliff+xml",
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+	".msg":      "application/vnd.ms-outlook",
+	".rtf":      "text/rtf",
+}
+
+// containerTypes lists the generic container a document format is built on. Sniffing a
+// short prefix sometimes only identifies the container, which is accepted for these types.
+var containerTypes = map[string]string{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   "application/zip",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": "application/zip",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         "application/zip",
+	"application/vnd.oasis.opendocument.text":                                   "application/zip",
+	"application/vnd.oasis.opendocument.presentation":                           "application/zip",
+	"application/vnd.oasis.opendocument.spreadsheet":                            "application/zip",
+	"application/msword":            "application/x-ole-storage",
+	"application/vnd.ms-powerpoint": "application/x-ole-storage",
+	"application/vnd.ms-excel":      "application/x-ole-storage",
+	"application/vnd.ms-outlook":    "application/x-ole-storage",
+}
+
+// errContentNotInspectable is returned for uploads whose stream cannot be peeked at.
+var errContentNotInspectable = errors.New("file content cannot be inspected")
+
+// FileContentValidator checks the magic bytes of a file, rather than the client supplied
+// Content-Type, against its extension and the allowed types.
+type FileContentValidator struct {
+	AllowedTypes []string // List of allowed file types
+}
+
+// Validate detects the file type from its leading bytes. The type implied by the file's
+// extension must be allowed and the detected content must be of that type. Files without
+// content yet, such as announced resumable uploads, are accepted.
+func (v *FileContentValidator) Validate(file domain.UploadedFile) error {
+	if file.File == nil {
+		return nil
+	}
+
+	extType := typeByExtension(file.Name)
+	if extType == "" || !v.allowed(extType) {
+		return domain.NewValidationError(domain.ErrFileTypeInvalid, domain.ValidationCodeTypeNotAllowed, "filename",
+			fmt.Sprintf("files named %q are not of an allowed type", file.Name), v.AllowedTypes, extType)
+	}
+
+	head, err := peek(file.File, sniffLength)
+	if err != nil {
+		return domain.NewValidationError(err, domain.ValidationCodeContentUnreadable, "content",
+			"file content cannot be inspected", nil, nil)
+	}
+	if detected := mimetype.Detect(head); !contentMatches(detected, extType) {
+		return domain.NewValidationError(domain.ErrFileContentMismatch, domain.ValidationCodeContentMismatch, "content",
+			fmt.Sprintf("file content looks like %s, not %s", detected.String(), extType), extType, detected.String())
+	}
+	return nil
+}
+
+func (v *FileContentValidator) allowed(contentType string) bool {
+	for _, allowedType := range v.AllowedTypes {
+		if contentType == allowedType {
+			return true
+		}
+	}
+	return false
+}
+
+// NewFileContentValidator creates a new FileContentValidator.
+func NewFileContentValidator(allowedTypes []string) *FileContentValidator {
+	return &FileContentValidator{
+		AllowedTypes: allowedTypes,
+	}
+}
+
+// typeByExtension returns the MIME type implied by name's extension, or "" if unknown.
+func typeByExtension(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if contentType, ok := extensionTypes[ext]; ok {
+		return contentType
+	}
+	mediaType, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext))
+	return mediaType
+}
+
+// contentMatches reports whether the detected type, or one of its ancestors, is want or
+// want's container. Any text satisfies text based types such as CSV or Markdown, which
+// have no reliable signature.
+func contentMatches(detected *mimetype.MIME, want string) bool {
+	container := containerTypes[want]
+	textual := strings.HasPrefix(want, "text/") || strings.HasSuffix(want, "+xml")
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(want) || (container != "" && m.Is(container)) || (textual && m.Is("text/plain")) {
+			return true
+		}
+	}
+	return false
+}
+
+// peek returns up to n leading bytes of r without consuming them. r must either buffer
+// (like *bufio.Reader) or be seekable (like multipart.File); seekable readers are
+// rewound to where they were.
+func peek(r io.Reader, n int) ([]byte, error) {
+	switch src := r.(type) {
+	case interface{ Peek(int) ([]byte, error) }:
+		head, err := src.Peek(n)
+		if len(head) == 0 && err != nil && err != io.EOF {
+			return nil, err
+		}
+		return head, nil
+	case io.ReadSeeker:
+		pos, err := src.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		head := make([]byte, n)
+		read, err := io.ReadFull(src, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		if _, err := src.Seek(pos, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return head[:read], nil
+	default:
+		return nil, errContentNotInspectable
+	}
+}
@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestFileContentValidator_Validate(t *testing.T) {
+	validator := &FileContentValidator{AllowedTypes: []string{
+		"application/pdf", "text/plain", "text/csv", "application/msword",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	}}
+
+	pdf := "%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"
+	exe := "MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00"
+	elf := "\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+	zip := "PK\x03\x04\x14\x00\x00\x00\x08\x00"
+	ole := "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1" + strings.Repeat("\x00", 64)
+
+	tests := []struct {
+		name     string
+		file     domain.UploadedFile
+		expected error
+	}{
+		{
+			name:     "pdf",
+			file:     domain.UploadedFile{Name: "report.pdf", File: strings.NewReader(pdf)},
+			expected: nil,
+		},
+		{
+			name:     "upper case extension",
+			file:     domain.UploadedFile{Name: "REPORT.PDF", File: strings.NewReader(pdf)},
+			expected: nil,
+		},
+		{
+			name:     "csv text",
+			file:     domain.UploadedFile{Name: "data.csv", File: strings.NewReader("a,b\n1,2\n")},
+			expected: nil,
+		},
+		{
+			name:     "docx detected as zip container",
+			file:     domain.UploadedFile{Name: "letter.docx", File: strings.NewReader(zip)},
+			expected: nil,
+		},
+		{
+			name:     "doc detected as ole container",
+			file:     domain.UploadedFile{Name: "letter.doc", File: strings.NewReader(ole)},
+			expected: nil,
+		},
+		{
+			name:     "executable renamed to pdf",
+			file:     domain.UploadedFile{Name: "report.pdf", ContentType: "application/pdf", File: strings.NewReader(exe)},
+			expected: domain.ErrFileContentMismatch,
+		},
+		{
+			name:     "elf renamed to txt",
+			file:     domain.UploadedFile{Name: "notes.txt", ContentType: "text/plain", File: strings.NewReader(elf)},
+			expected: domain.ErrFileContentMismatch,
+		},
+		{
+			name:     "pdf renamed to doc",
+			file:     domain.UploadedFile{Name: "letter.doc", File: strings.NewReader(pdf)},
+			expected: domain.ErrFileContentMismatch,
+		},
+		{
+			name:     "extension not allowed",
+			file:     domain.UploadedFile{Name: "setup.exe", File: strings.NewReader(exe)},
+			expected: domain.ErrFileTypeInvalid,
+		},
+		{
+			name:     "no extension",
+			file:     domain.UploadedFile{Name: "report", File: strings.NewReader(pdf)},
+			expected: domain.ErrFileTypeInvalid,
+		},
+		{
+			name:     "no content yet",
+			file:     domain.UploadedFile{Name: "report.pdf"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.file)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("FileContentValidator.Validate(%v) = %v, want %v", tt.file.Name, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileContentValidator_DoesNotConsumeStream(t *testing.T) {
+	validator := NewFileContentValidator([]string{"application/pdf"})
+	content := "%PDF-1.7\n" + strings.Repeat("x", 2*sniffLength)
+
+	tests := []struct {
+		name   string
+		reader io.Reader
+	}{
+		{name: "seeker", reader: strings.NewReader(content)},
+		{name: "buffered reader", reader: bufio.NewReader(bytes.NewBufferString(content))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validator.Validate(domain.UploadedFile{Name: "report.pdf", File: tt.reader}); err != nil {
+				t.Fatalf("FileContentValidator.Validate() = %v, want nil", err)
+			}
+			got, err := io.ReadAll(tt.reader)
+			if err != nil || string(got) != content {
+				t.Errorf("content after Validate() = %d bytes (err %v), want all %d bytes", len(got), err, len(content))
+			}
+		})
+	}
+}
+
+func TestFileContentValidator_RejectsUninspectableStreams(t *testing.T) {
+	validator := NewFileContentValidator([]string{"application/pdf"})
+	file := domain.UploadedFile{Name: "report.pdf", File: io.MultiReader(strings.NewReader("%PDF-1.7"))}
+	if err := validator.Validate(file); err == nil {
+		t.Errorf("FileContentValidator.Validate() = nil, want an error for a stream that cannot be peeked")
+	}
+}
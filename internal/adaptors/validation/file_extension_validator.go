@@ -0,0 +1,33 @@
+// internal/adaptors/validation/file_extension_validator.go
+package validation
+
+import (
+	"chat-backend-general/internal/domain"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FileExtensionValidator is a concrete implementation of the FileValidator interface.
+type FileExtensionValidator struct {
+	AllowedExtensions []string // List of allowed extensions, lower case with a leading dot
+}
+
+// Validate checks if the file name ends in one of the allowed extensions.
+func (v *FileExtensionValidator) Validate(file domain.UploadedFile) error {
+	ext := strings.ToLower(filepath.Ext(file.Name))
+	for _, allowedExtension := range v.AllowedExtensions {
+		if ext == allowedExtension {
+			return nil
+		}
+	}
+	return domain.NewValidationError(domain.ErrFileExtensionInvalid, domain.ValidationCodeExtensionNotAllowed, "filename",
+		fmt.Sprintf("file extension %q is not allowed", ext), v.AllowedExtensions, ext)
+}
+
+// NewFileExtensionValidator creates a new FileExtensionValidator.
+func NewFileExtensionValidator(allowedExtensions []string) *FileExtensionValidator {
+	return &FileExtensionValidator{
+		AllowedExtensions: allowedExtensions,
+	}
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestFileExtensionValidator_Validate(t *testing.T) {
+	validator := &FileExtensionValidator{AllowedExtensions: []string{".pdf", ".docx"}}
+
+	tests := []struct {
+		name     string
+		file     domain.UploadedFile
+		expected error
+	}{
+		{
+			name:     "valid extension",
+			file:     domain.UploadedFile{Name: "report.pdf"},
+			expected: nil,
+		},
+		{
+			name:     "upper case extension",
+			file:     domain.UploadedFile{Name: "REPORT.DOCX"},
+			expected: nil,
+		},
+		{
+			name:     "invalid extension",
+			file:     domain.UploadedFile{Name: "setup.exe"},
+			expected: domain.ErrFileExtensionInvalid,
+		},
+		{
+			name:     "double extension",
+			file:     domain.UploadedFile{Name: "report.pdf.exe"},
+			expected: domain.ErrFileExtensionInvalid,
+		},
+		{
+			name:     "no extension",
+			file:     domain.UploadedFile{Name: "report"},
+			expected: domain.ErrFileExtensionInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.file)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("FileExtensionValidator.Validate(%v) = %v, want %v", tt.file, err, tt.expected)
+			}
+		})
+	}
+}
@@ -3,24 +3,34 @@ package validation
 
 import (
 	"chat-backend-general/internal/domain"
+	"fmt"
 )
 
 // FileSizeValidator is a concrete implementation of the FileValidator interface.
 type FileSizeValidator struct {
-	MaxSize int64 // Maximum allowed file size in bytes
+	MaxSize    int64            // Maximum allowed file size in bytes
+	TypeLimits map[string]int64 // Maximum size per content type, overriding MaxSize
 }
 
-// Validate checks if the file size is within the allowed limit.
+// Validate checks if the file size is within the allowed limit for its type. The type is
+// taken from the file's extension, which FileContentValidator holds the content to, rather
+// than from the client supplied Content-Type, so a file cannot claim a larger limit.
 func (v *FileSizeValidator) Validate(file domain.UploadedFile) error {
-	if file.Size > v.MaxSize {
-		return domain.ErrFileSizeExceeded
+	limit := v.MaxSize
+	if typeLimit, ok := v.TypeLimits[typeByExtension(file.Name)]; ok {
+		limit = typeLimit
+	}
+	if file.Size > limit {
+		return domain.NewValidationError(domain.ErrFileSizeExceeded, domain.ValidationCodeFileTooLarge, "size",
+			fmt.Sprintf("file size of %d bytes exceeds the limit of %d bytes", file.Size, limit), limit, file.Size)
 	}
 	return nil
 }
 
 // NewFileSizeValidator creates a new FileSizeValidator.
-func NewFileSizeValidator(maxSize int64) *FileSizeValidator {
+func NewFileSizeValidator(maxSize int64, typeLimits map[string]int64) *FileSizeValidator {
 	return &FileSizeValidator{
-		MaxSize: maxSize,
+		MaxSize:    maxSize,
+		TypeLimits: typeLimits,
 	}
 }
@@ -1,13 +1,14 @@
 package validation
 
 import (
+	"errors"
 	"testing"
 
 	"chat-backend-general/internal/domain"
 )
 
 func TestFileSizeValidator_Validate(t *testing.T) {
-	validator := &FileSizeValidator{MaxSize: 1024}
+	validator := &FileSizeValidator{MaxSize: 1024, TypeLimits: map[string]int64{"application/pdf": 4096}}
 
 	tests := []struct {
 		name     string
@@ -24,14 +25,47 @@ func TestFileSizeValidator_Validate(t *testing.T) {
 			file:     domain.UploadedFile{Size: 2048},
 			expected: domain.ErrFileSizeExceeded,
 		},
+		{
+			name:     "within type limit",
+			file:     domain.UploadedFile{Name: "report.pdf", Size: 2048, ContentType: "application/pdf"},
+			expected: nil,
+		},
+		{
+			name:     "type limit by extension",
+			file:     domain.UploadedFile{Name: "REPORT.PDF", Size: 2048},
+			expected: nil,
+		},
+		{
+			name:     "exceeded type limit",
+			file:     domain.UploadedFile{Name: "report.pdf", Size: 8192, ContentType: "application/pdf"},
+			expected: domain.ErrFileSizeExceeded,
+		},
+		{
+			name:     "larger limit claimed by content type",
+			file:     domain.UploadedFile{Name: "notes.txt", Size: 2048, ContentType: "application/pdf"},
+			expected: domain.ErrFileSizeExceeded,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := validator.Validate(tt.file)
-			if err != tt.expected {
+			if !errors.Is(err, tt.expected) {
 				t.Errorf("FileSizeValidator.Validate(%v) = %v, want %v", tt.file, err, tt.expected)
 			}
 		})
 	}
 }
+
+func TestFileSizeValidator_ReportsLimit(t *testing.T) {
+	validator := NewFileSizeValidator(1024, nil)
+
+	var validationErr *domain.ValidationError
+	if err := validator.Validate(domain.UploadedFile{Size: 2048}); !errors.As(err, &validationErr) {
+		t.Fatalf("FileSizeValidator.Validate() = %v, want a *domain.ValidationError", err)
+	}
+	if validationErr.Code != domain.ValidationCodeFileTooLarge || validationErr.Field != "size" ||
+		validationErr.Limit != int64(1024) || validationErr.Actual != int64(2048) {
+		t.Errorf("FileSizeValidator.Validate() = %+v, want code %s with limit 1024 and actual 2048", validationErr, domain.ValidationCodeFileTooLarge)
+	}
+}
@@ -3,6 +3,7 @@ package validation
 
 import (
 	"chat-backend-general/internal/domain"
+	"fmt"
 )
 
 // FileTypeValidator is a concrete implementation of the FileValidator interface.
@@ -17,7 +18,8 @@ func (v *FileTypeValidator) Validate(file domain.UploadedFile) error {
 			return nil
 		}
 	}
-	return domain.ErrFileTypeInvalid
+	return domain.NewValidationError(domain.ErrFileTypeInvalid, domain.ValidationCodeTypeNotAllowed, "contentType",
+		fmt.Sprintf("file type %q is not allowed", file.ContentType), v.AllowedTypes, file.ContentType)
 }
 
 // NewFileTypeValidator creates a new FileTypeValidator.
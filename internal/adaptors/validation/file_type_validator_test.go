@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"errors"
 	"testing"
 
 	"chat-backend-general/internal/domain"
@@ -29,7 +30,7 @@ func TestFileTypeValidator_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := validator.Validate(tt.file)
-			if err != tt.expected {
+			if !errors.Is(err, tt.expected) {
 				t.Errorf("FileTypeValidator.Validate(%v) = %v, want %v", tt.file, err, tt.expected)
 			}
 		})
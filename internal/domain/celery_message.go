@@ -6,15 +6,25 @@ import (
 	"github.com/google/uuid"
 )
 
+// CeleryMessage is a task sent to Celery workers. The broker adapters encode it with the
+// Celery message protocol v2.
 type CeleryMessage struct {
-	Task    string                 `json:"task"`
-	Args    []interface{}          `json:"args"`
-	Kwargs  map[string]interface{} `json:"kwargs"`
-	ID      string                 `json:"id"`
-	ETA     *time.Time             `json:"eta,omitempty"`
-	Expires *time.Time             `json:"expires,omitempty"`
+	Task     string                 `json:"task"`
+	Args     []interface{}          `json:"args"`
+	Kwargs   map[string]interface{} `json:"kwargs"`
+	ID       string                 `json:"id"`
+	RootID   string                 `json:"root_id,omitempty"`   // First task of the workflow; the task itself when empty
+	ParentID string                 `json:"parent_id,omitempty"` // Task that sent this one, if any
+	Retries  int                    `json:"retries,omitempty"`
+	Priority *int                   `json:"priority,omitempty"` // 0 (lowest) to MaxCeleryPriority; the broker's default when nil
+	ETA      *time.Time             `json:"eta,omitempty"`
+	Expires  *time.Time             `json:"expires,omitempty"`
 }
 
+// MaxCeleryPriority is the highest CeleryMessage priority. The broker adapters map the
+// priorities to the scale of their broker.
+const MaxCeleryPriority = 9
+
 // NewCeleryMessage creates a Celery-compatible message
 func NewCeleryMessage(task string, args []interface{}, kwargs map[string]interface{}) CeleryMessage {
 	return CeleryMessage{
@@ -9,5 +9,8 @@ type UploadedFile struct {
 	ContentType string
 	File        io.Reader
 	Size        int64
-	Path        string
+	Path        string // Storage key, assigned by the upload use case
+	Owner       string
+	ChatID      string
+	Checksum    string // Hex-encoded SHA-256 the client expects the content to have, if any
 }
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FileStatus is the lifecycle state of an uploaded file.
+type FileStatus string
+
+const (
+	FileStatusPending     FileStatus = "pending"     // Metadata written, content not stored yet
+	FileStatusStored      FileStatus = "stored"      // Content stored successfully
+	FileStatusFailed      FileStatus = "failed"      // Storing the content failed
+	FileStatusDeleted     FileStatus = "deleted"     // Deleted by the owner, content kept until the retention period ends
+	FileStatusPurged      FileStatus = "purged"      // Deleted and content removed from storage
+	FileStatusRejected    FileStatus = "rejected"    // Content found infected or invalid and deleted
+	FileStatusQuarantined FileStatus = "quarantined" // Content found infected and moved to quarantine
+)
+
+// FileRecord is the metadata kept for every uploaded file, so other services can
+// reference documents by ID instead of by storage path.
+type FileRecord struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	ChatID       string `json:"chatId"`
+	OriginalName string `json:"originalName"`
+	Version      int    `json:"version"` // Uploads of OriginalName to the chat are versions of one document, from 1
+	ContentType  string `json:"contentType"`
+	Size         int64  `json:"size"`
+	Checksum     string `json:"checksum,omitempty"` // Hex-encoded SHA-256 of the content
+	StoragePath  string `json:"storagePath"`
+	// BlobPath is where the content is stored when it is shared with an earlier upload
+	// of identical content by the same owner; empty when the content is at StoragePath.
+	BlobPath  string     `json:"blobPath,omitempty"`
+	Status    FileStatus `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+
+	ScanVerdict   ScanVerdict `json:"scanVerdict,omitempty"` // Empty when scanning is disabled
+	ScanSignature string      `json:"scanSignature,omitempty"`
+	ScannedAt     *time.Time  `json:"scannedAt,omitempty"`
+}
+
+// ContentPath returns the storage key holding the record's content.
+func (r FileRecord) ContentPath() string {
+	if r.BlobPath != "" {
+		return r.BlobPath
+	}
+	return r.StoragePath
+}
+
+// UploadResult is returned to clients once an upload has been stored.
+type UploadResult struct {
+	FileID   string `json:"fileId"`
+	Path     string `json:"path"`
+	Checksum string `json:"checksum"` // Hex-encoded SHA-256 of the content
+	Version  int    `json:"version"`
+	TaskID   string `json:"taskId,omitempty"` // Ingestion task published for the file, if any
+}
+
+// BatchUploadResult is the outcome of one file of a batch upload: its UploadResult, or
+// the error that stopped it, such as ValidationErrors.
+type BatchUploadResult struct {
+	Name   string
+	Result UploadResult
+	Err    error
+}
+
+// FileMetadataRepository persists FileRecords.
+type FileMetadataRepository interface {
+	CreateFileRecord(ctx context.Context, record FileRecord) error
+	UpdateFileRecord(ctx context.Context, record FileRecord) error
+	GetFileRecord(ctx context.Context, id string) (FileRecord, error)
+	// GetFileRecordByPath returns the newest record of a file that is neither deleted nor purged.
+	GetFileRecordByPath(ctx context.Context, path string) (FileRecord, error)
+	// GetUsage sums the pending and stored files of owner, or of one of its chats when chatID is set.
+	GetUsage(ctx context.Context, owner, chatID string) (Usage, error)
+	// ListFileRecords returns the records of every file of owner's chat, in any status.
+	ListFileRecords(ctx context.Context, owner, chatID string) ([]FileRecord, error)
+	// FindFileRecordByChecksum returns the oldest stored record of owner with checksum.
+	FindFileRecordByChecksum(ctx context.Context, owner, checksum string) (FileRecord, error)
+	// CountContentReferences counts the pending, stored and deleted records whose content is at path.
+	CountContentReferences(ctx context.Context, path string) (int64, error)
+	// ListDocumentVersions returns the records of owner's chat named name, newest version first.
+	ListDocumentVersions(ctx context.Context, owner, chatID, name string) ([]FileRecord, error)
+	// ListPurgeableFileRecords returns up to limit deleted records, deleted at or before deletedBy.
+	ListPurgeableFileRecords(ctx context.Context, deletedBy time.Time, limit int) ([]FileRecord, error)
+	// ListStalePendingFileRecords returns up to limit pending records, created at or before createdBy.
+	ListStalePendingFileRecords(ctx context.Context, createdBy time.Time, limit int) ([]FileRecord, error)
+}
+
+// ErrFileRecordNotFound is returned when no metadata exists for a file ID.
+var ErrFileRecordNotFound = errors.New("file record not found")
+
+// ErrVersionConflict is returned when a record is created with a version of its document that already exists.
+var ErrVersionConflict = errors.New("document version already exists")
+
+// ErrVersionUnavailable is returned when restoring a document version whose content is no longer stored.
+var ErrVersionUnavailable = errors.New("document version is not available")
+
+// ErrChecksumMismatch is returned when uploaded content does not match the digest sent by the client.
+var ErrChecksumMismatch = errors.New("content does not match its digest")
@@ -1,6 +1,54 @@
 package domain
 
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// FileInfo describes a file held by a FileRepository.
+type FileInfo struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	ContentType  string    `json:"contentType,omitempty"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// StoredFile is a file read back from a FileRepository. Content must be closed by the caller.
+// FileInfo always describes the whole file, even when only a range of it was requested.
+type StoredFile struct {
+	FileInfo
+	Content io.ReadCloser
+}
+
+// ByteRange selects Length bytes of a file starting at Offset.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// FileRepository is implemented by the storage backends that hold uploaded file content.
+// Files are addressed by their storage path, e.g. "username/chatid/filename".
 type FileRepository interface {
-	SaveFile(file UploadedFile) error
-	GetFile(id string) (UploadedFile, error)
+	SaveFile(ctx context.Context, file UploadedFile) error
+	StatFile(ctx context.Context, path string) (FileInfo, error)
+	// GetFile streams the file, or only rng of it when rng is not nil.
+	GetFile(ctx context.Context, path string, rng *ByteRange) (StoredFile, error)
+	ListFiles(ctx context.Context, prefix string) ([]FileInfo, error)
+	DeleteFile(ctx context.Context, path string) error
+}
+
+// ChecksumRecorder is implemented by FileRepository backends that can attach the SHA-256
+// checksum of a stored file to it as metadata, once the file has been streamed.
+type ChecksumRecorder interface {
+	RecordChecksum(ctx context.Context, path, checksum string) error
 }
+
+// ErrFileNotFound is returned when the requested file does not exist in storage.
+var ErrFileNotFound = errors.New("file not found")
+
+// ErrInvalidPath is returned when a storage path is empty or escapes its namespace.
+var ErrInvalidPath = errors.New("invalid file path")
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ScanVerdict is the outcome of scanning a file for malware.
+type ScanVerdict string
+
+const (
+	ScanVerdictClean    ScanVerdict = "clean"
+	ScanVerdictInfected ScanVerdict = "infected"
+)
+
+// ScanResult is what a FileScanner found in a file.
+type ScanResult struct {
+	Verdict   ScanVerdict
+	Signature string // Name of the detected malware, set for infected files
+}
+
+// FileScanner scans file content for malware.
+type FileScanner interface {
+	Scan(ctx context.Context, content io.Reader) (ScanResult, error)
+}
+
+// ScanAction is what happens to an infected upload.
+type ScanAction string
+
+const (
+	ScanActionReject     ScanAction = "reject"     // Delete the file
+	ScanActionQuarantine ScanAction = "quarantine" // Move the file out of its owner's reach
+)
+
+// ErrFileInfected is returned when a scanner detected malware in an upload.
+var ErrFileInfected = errors.New("file is infected")
+
+// ErrScanFailed is returned when an upload could not be scanned.
+var ErrScanFailed = errors.New("file could not be scanned")
@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 // FileValidator is an interface for file validation.
 type FileValidator interface {
@@ -10,5 +13,96 @@ type FileValidator interface {
 // ErrFileTypeInvalid is returned when the file type is invalid.
 var ErrFileTypeInvalid = errors.New("invalid file type")
 
+// ErrFileExtensionInvalid is returned when the file extension is not allowed.
+var ErrFileExtensionInvalid = errors.New("invalid file extension")
+
 // ErrFileSizeExceeded is returned when the file size exceeds the allowed limit.
 var ErrFileSizeExceeded = errors.New("file size exceeded")
+
+// ErrFileContentMismatch is returned when the file content does not match its name or allowed types.
+var ErrFileContentMismatch = errors.New("file content does not match its type")
+
+// ErrUnsafeArchive is returned for ZIP based files that are malformed or could overwhelm
+// the services processing them.
+var ErrUnsafeArchive = errors.New("unsafe archive")
+
+// Machine-readable codes of ValidationErrors.
+const (
+	ValidationCodeFileTooLarge        = "file_too_large"
+	ValidationCodeTypeNotAllowed      = "type_not_allowed"
+	ValidationCodeExtensionNotAllowed = "extension_not_allowed"
+	ValidationCodeContentMismatch     = "content_mismatch"
+	ValidationCodeContentUnreadable   = "content_unreadable"
+	ValidationCodeInvalidFile         = "invalid_file"
+	ValidationCodeArchiveTooManyFiles = "archive_too_many_entries"
+	ValidationCodeArchiveTooLarge     = "archive_too_large"
+	ValidationCodeArchiveRatio        = "archive_ratio_exceeded"
+	ValidationCodeArchiveNested       = "archive_nested"
+	ValidationCodeMacrosNotAllowed    = "macros_not_allowed"
+)
+
+// ValidationError is a structured validation failure that clients can localize from
+// Code, Limit and Actual. It matches its sentinel error with errors.Is.
+type ValidationError struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"` // Part of the upload that failed: size, contentType, filename or content
+	Message string `json:"message"`
+	Limit   any    `json:"limit,omitempty"`
+	Actual  any    `json:"actual,omitempty"`
+	err     error
+}
+
+// NewValidationError creates a ValidationError that wraps err.
+func NewValidationError(err error, code, field, message string, limit, actual any) *ValidationError {
+	return &ValidationError{Code: code, Field: field, Message: message, Limit: limit, Actual: actual, err: err}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// ValidationErrors collects the failures of several validators.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap lets errors.Is and errors.As match any of the collected failures.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ValidateFile runs every validator against file and returns all failures as
+// ValidationErrors, or nil when the file is valid. Plain errors from validators are
+// reported with ValidationCodeInvalidFile.
+func ValidateFile(validators []FileValidator, file UploadedFile) error {
+	var failures ValidationErrors
+	for _, validator := range validators {
+		err := validator.Validate(file)
+		if err == nil {
+			continue
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			validationErr = NewValidationError(err, ValidationCodeInvalidFile, "file", err.Error(), nil, nil)
+		}
+		failures = append(failures, validationErr)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return failures
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// validatorFunc adapts a function to the FileValidator interface.
+type validatorFunc func(file UploadedFile) error
+
+func (f validatorFunc) Validate(file UploadedFile) error {
+	return f(file)
+}
+
+func TestValidateFile(t *testing.T) {
+	tooLarge := validatorFunc(func(file UploadedFile) error {
+		return NewValidationError(ErrFileSizeExceeded, ValidationCodeFileTooLarge, "size", "too large", int64(1), file.Size)
+	})
+	plain := validatorFunc(func(file UploadedFile) error { return errors.New("scanner offline") })
+	valid := validatorFunc(func(file UploadedFile) error { return nil })
+
+	if err := ValidateFile([]FileValidator{valid, valid}, UploadedFile{}); err != nil {
+		t.Errorf("ValidateFile(valid) = %v, want nil", err)
+	}
+
+	err := ValidateFile([]FileValidator{tooLarge, valid, plain}, UploadedFile{Size: 2})
+	var failures ValidationErrors
+	if !errors.As(err, &failures) || len(failures) != 2 {
+		t.Fatalf("ValidateFile() = %v, want 2 ValidationErrors", err)
+	}
+	if failures[0].Code != ValidationCodeFileTooLarge || failures[0].Actual != int64(2) {
+		t.Errorf("ValidateFile()[0] = %+v, want %s with actual 2", failures[0], ValidationCodeFileTooLarge)
+	}
+	if failures[1].Code != ValidationCodeInvalidFile || failures[1].Message != "scanner offline" {
+		t.Errorf("ValidateFile()[1] = %+v, want %s with the validator's message", failures[1], ValidationCodeInvalidFile)
+	}
+	if !errors.Is(err, ErrFileSizeExceeded) {
+		t.Errorf("errors.Is(ValidateFile(), ErrFileSizeExceeded) = false, want true")
+	}
+}
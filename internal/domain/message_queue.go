@@ -1,5 +1,28 @@
 package domain
 
+import "errors"
+
 type MessageQueue interface {
 	PublishMessage(queueName string, message CeleryMessage) error
 }
+
+// MessageScheduler is implemented by MessageQueue adapters that hold messages with an ETA
+// back until it is reached, and can cancel such a scheduled message in the meantime. The
+// broker keeps track of scheduled messages, so any instance can cancel them.
+type MessageScheduler interface {
+	// ScheduleMessage publishes message, whose ETA is in the future, and returns the ID
+	// that cancels it, or an empty ID when the ETA has passed and it was not held back.
+	ScheduleMessage(queueName string, message CeleryMessage) (string, error)
+	// CancelScheduledMessage cancels the message scheduled on queueName with scheduleID.
+	CancelScheduledMessage(queueName, scheduleID string) error
+}
+
+// ErrMessageExpired is returned when a message expires before it would be delivered.
+var ErrMessageExpired = errors.New("message expires before it can be delivered")
+
+// ErrScheduledMessageNotFound is returned when no scheduled message has the given schedule
+// ID, or when it has already been delivered.
+var ErrScheduledMessageNotFound = errors.New("scheduled message not found")
+
+// ErrSchedulingUnsupported is returned when the message broker cannot cancel scheduled messages.
+var ErrSchedulingUnsupported = errors.New("message broker does not support cancelling scheduled messages")
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// OutboxMessage is a message waiting to be published to Queue. Messages are written in the
+// same transaction as the file metadata they belong to and published by a relay, so a
+// message is never lost once the metadata is saved. It may be published more than once.
+type OutboxMessage struct {
+	ID            string // ID of Message
+	Queue         string
+	Message       CeleryMessage
+	Attempts      int    // Publish attempts started so far
+	LastError     string // Error of the last failed attempt
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+}
+
+// OutboxRepository persists OutboxMessages until they are published.
+type OutboxRepository interface {
+	// UpdateFileRecordWithMessage updates record and adds message to the outbox atomically.
+	UpdateFileRecordWithMessage(ctx context.Context, record FileRecord, message OutboxMessage) error
+	// ClaimOutboxMessages returns up to limit messages due at now, those due longest first,
+	// and hides them from other claims for lease, counting an attempt for each. A claimed message
+	// that is neither deleted nor retried before the lease ends is claimed again.
+	ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]OutboxMessage, error)
+	// RetryOutboxMessage schedules the next attempt of a message whose publishing failed.
+	RetryOutboxMessage(ctx context.Context, id string, nextAttemptAt time.Time, lastError string) error
+	// DeleteOutboxMessage removes a published message.
+	DeleteOutboxMessage(ctx context.Context, id string) error
+}
+
+// ErrOutboxMessageNotFound is returned when an outbox message does not exist, e.g. because
+// another relay published it after its lease ended.
+var ErrOutboxMessageNotFound = errors.New("outbox message not found")
@@ -0,0 +1,32 @@
+package domain
+
+import "errors"
+
+// Usage is the storage consumed by a user or chat.
+type Usage struct {
+	Files int64 `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// QuotaLimits caps the storage of a user and of each of its chats. Zero means unlimited.
+type QuotaLimits struct {
+	MaxBytes     int64 `json:"maxBytes"`
+	MaxFiles     int64 `json:"maxFiles"`
+	ChatMaxBytes int64 `json:"chatMaxBytes"`
+	ChatMaxFiles int64 `json:"chatMaxFiles"`
+}
+
+// QuotaReport describes the usage of a user, and optionally of one chat, against its limits.
+type QuotaReport struct {
+	Username  string      `json:"username"`
+	Usage     Usage       `json:"usage"`
+	ChatID    string      `json:"chatId,omitempty"`
+	ChatUsage *Usage      `json:"chatUsage,omitempty"`
+	Limits    QuotaLimits `json:"limits"`
+}
+
+// ErrQuotaBytesExceeded is returned when an upload would exceed the storage quota.
+var ErrQuotaBytesExceeded = errors.New("storage quota exceeded")
+
+// ErrQuotaFilesExceeded is returned when an upload would exceed the file count quota.
+var ErrQuotaFilesExceeded = errors.New("file count quota exceeded")
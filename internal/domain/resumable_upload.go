@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"time"
+)
+
+// UploadSession tracks a resumable upload whose content arrives in several requests.
+type UploadSession struct {
+	ID          string
+	FileID      string // ID of the FileRecord created for the upload
+	Owner       string
+	ChatID      string
+	Path        string
+	Name        string
+	ContentType string
+	Length      int64  // Total size announced by the client
+	Offset      int64  // Bytes received and staged so far
+	Chunks      int    // Number of chunks staged so far
+	HashState   []byte // Marshalled SHA-256 state over the bytes received so far
+	TaskID      string // Ingestion task published once the upload completed, if any
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether all bytes of the upload have been received.
+func (s UploadSession) Completed() bool {
+	return s.Offset == s.Length
+}
+
+// UploadChecksum is the checksum a client sent for the body of one request to a resumable
+// upload. A body that does not match it is discarded.
+type UploadChecksum struct {
+	Algorithm string // One of ChecksumAlgorithms
+	Sum       []byte
+}
+
+// ChecksumAlgorithms lists the algorithms of UploadChecksum, named as by the tus checksum extension.
+var ChecksumAlgorithms = []string{"sha1", "sha256"}
+
+// NewHash returns a hash computing the checksum, or nil when its algorithm is not supported.
+func (c UploadChecksum) NewHash() hash.Hash {
+	switch c.Algorithm {
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	default:
+		return nil
+	}
+}
+
+// UploadSessionStore persists the state of resumable uploads between requests.
+type UploadSessionStore interface {
+	CreateSession(ctx context.Context, session UploadSession) error
+	GetSession(ctx context.Context, id string) (UploadSession, error)
+	UpdateSession(ctx context.Context, session UploadSession) error
+	DeleteSession(ctx context.Context, id string) error
+}
+
+// ChunkedUploader is implemented by FileRepository backends that can stage a file
+// in numbered chunks and assemble them into session.Path once all have arrived.
+type ChunkedUploader interface {
+	StageChunk(ctx context.Context, session UploadSession, index int, chunk []byte) error
+	CommitChunks(ctx context.Context, session UploadSession) error
+	AbortChunks(ctx context.Context, session UploadSession) error
+}
+
+// ErrUploadNotFound is returned for unknown or expired upload sessions.
+var ErrUploadNotFound = errors.New("upload not found")
+
+// ErrUploadOffsetMismatch is returned when a chunk does not continue where the upload left off.
+var ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+
+// ErrChecksumAlgorithmUnsupported is returned for an UploadChecksum of an algorithm not in ChecksumAlgorithms.
+var ErrChecksumAlgorithmUnsupported = errors.New("checksum algorithm not supported")
+
+// ErrUploadTooLarge is returned when a chunk would exceed the announced upload length.
+var ErrUploadTooLarge = errors.New("upload exceeds announced length")
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SignedURLOperation is the operation a signed URL grants.
+type SignedURLOperation string
+
+const (
+	SignedURLDownload SignedURLOperation = "download"
+	SignedURLUpload   SignedURLOperation = "upload"
+)
+
+// SignedURL is a time-limited URL that lets a client access storage directly.
+// Clients must send Method and any Headers along with the request.
+type SignedURL struct {
+	URL       string            `json:"url"`
+	Path      string            `json:"path"` // Storage key the URL points at
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	FileID    string            `json:"fileId,omitempty"` // Pending record of a signed upload, stored once finalized
+}
+
+// URLSigner is implemented by FileRepository backends that can hand out signed URLs.
+// Uploads are signed for exactly size bytes where the provider can enforce it; size is
+// ignored for downloads.
+type URLSigner interface {
+	SignURL(ctx context.Context, path string, op SignedURLOperation, expiry time.Duration, size int64) (SignedURL, error)
+}
+
+// SignedURLVerifier is implemented by backends whose signed URLs are served by this
+// service itself rather than by the storage provider.
+type SignedURLVerifier interface {
+	VerifySignedURL(path string, op SignedURLOperation, expires, size int64, signature string) error
+}
+
+// ErrNotSupported is returned when the configured backend lacks an optional capability.
+var ErrNotSupported = errors.New("operation not supported by the storage provider")
+
+// ErrInvalidSignature is returned for signed URLs that are forged, altered or expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// ErrUploadSizeMismatch is returned when the content sent to a signed URL is not of the size announced when it was signed.
+var ErrUploadSizeMismatch = errors.New("uploaded content does not have the announced size")
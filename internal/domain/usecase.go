@@ -4,5 +4,5 @@ import "context"
 
 // FileUploadUseCase defines the interface for uploading files.
 type FileUploadUseCase interface {
-	HandleFileUpload(ctx context.Context, file UploadedFile) error
+	HandleFileUpload(ctx context.Context, file UploadedFile) (UploadResult, error)
 }
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX a ON files (owner);")},
+		"0002_add_index.down.sql":    {Data: []byte("DROP INDEX a;")},
+		"0001_create_files.up.sql":   {Data: []byte("CREATE TABLE files ();")},
+		"0001_create_files.down.sql": {Data: []byte("DROP TABLE files;")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("LoadMigrations() returned %d migrations, want 2", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_files" || migrations[0].Down != "DROP TABLE files;" {
+		t.Errorf("LoadMigrations()[0] = %+v, want version 1 create_files", migrations[0])
+	}
+	if migrations[1].Version != 2 || migrations[1].Up != "CREATE INDEX a ON files (owner);" {
+		t.Errorf("LoadMigrations()[1] = %+v, want version 2 add_index", migrations[1])
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"0001_create_files.up.sql": {Data: []byte("CREATE TABLE files ();")},
+		}},
+		{"invalid name", fstest.MapFS{
+			"create_files.sql": {Data: []byte("CREATE TABLE files ();")},
+		}},
+		{"duplicate version", fstest.MapFS{
+			"0001_create_files.up.sql":   {Data: []byte("CREATE TABLE files ();")},
+			"0001_create_files.down.sql": {Data: []byte("DROP TABLE files;")},
+			"0001_other.up.sql":          {Data: []byte("SELECT 1;")},
+			"0001_other.down.sql":        {Data: []byte("SELECT 1;")},
+		}},
+	}
+
+	for _, tt := range tests {
+		if _, err := LoadMigrations(tt.fsys); err == nil {
+			t.Errorf("LoadMigrations(%s) error = nil, want an error", tt.name)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		t.Fatalf("LoadMigrations(embedded) error = %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("embedded migration %d has version %d, want consecutive versions", i, migration.Version)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS files (
+    id            UUID PRIMARY KEY,
+    owner         TEXT        NOT NULL,
+    chat_id       TEXT        NOT NULL,
+    original_name TEXT        NOT NULL,
+    content_type  TEXT        NOT NULL DEFAULT '',
+    size          BIGINT      NOT NULL DEFAULT 0,
+    checksum      TEXT        NOT NULL DEFAULT '',
+    storage_path  TEXT        NOT NULL,
+    status        TEXT        NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS files_owner_chat_id_idx ON files (owner, chat_id);
+CREATE INDEX IF NOT EXISTS files_storage_path_idx ON files (storage_path);
@@ -0,0 +1,4 @@
+ALTER TABLE files
+    DROP COLUMN IF EXISTS scan_verdict,
+    DROP COLUMN IF EXISTS scan_signature,
+    DROP COLUMN IF EXISTS scanned_at;
@@ -0,0 +1,4 @@
+ALTER TABLE files
+    ADD COLUMN scan_verdict   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN scan_signature TEXT NOT NULL DEFAULT '',
+    ADD COLUMN scanned_at     TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS files_blob_path_idx;
+DROP INDEX IF EXISTS files_owner_checksum_idx;
+ALTER TABLE files DROP COLUMN IF EXISTS blob_path;
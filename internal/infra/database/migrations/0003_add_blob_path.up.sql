@@ -0,0 +1,4 @@
+ALTER TABLE files ADD COLUMN blob_path TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX files_owner_checksum_idx ON files (owner, checksum);
+CREATE INDEX files_blob_path_idx ON files (blob_path) WHERE blob_path <> '';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS files_deleted_at_idx;
+DROP INDEX IF EXISTS files_document_version_idx;
+ALTER TABLE files
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,14 @@
+ALTER TABLE files
+    ADD COLUMN version    INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Files uploaded before versioning become the versions of their document in upload order.
+UPDATE files SET version = numbered.version
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY owner, chat_id, original_name ORDER BY created_at, id) AS version
+    FROM files
+) AS numbered
+WHERE files.id = numbered.id;
+
+CREATE UNIQUE INDEX files_document_version_idx ON files (owner, chat_id, original_name, version);
+CREATE INDEX files_deleted_at_idx ON files (deleted_at) WHERE status = 'deleted';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS files_pending_created_at_idx;
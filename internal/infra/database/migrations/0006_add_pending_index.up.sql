@@ -0,0 +1 @@
+CREATE INDEX files_pending_created_at_idx ON files (created_at) WHERE status = 'pending';
@@ -1 +1,36 @@
 package database
+
+import (
+	"chat-backend-general/config"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" database/sql driver
+)
+
+// NewPostgresDB opens a connection pool to Postgres and checks that the server is reachable.
+func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+	if cfg.Url == "" {
+		return nil, errors.New("database url is empty")
+	}
+
+	db, err := sql.Open("pgx", cfg.Url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return db, nil
+}
@@ -4,11 +4,18 @@ import (
 	"chat-backend-general/config"
 	usecasesHttp "chat-backend-general/internal/adaptors/http"
 	usecasesMq "chat-backend-general/internal/adaptors/mq"
+	usecasesRepository "chat-backend-general/internal/adaptors/repository"
+	usecasesScanning "chat-backend-general/internal/adaptors/scanning"
 	usecasesStorage "chat-backend-general/internal/adaptors/storage"
 	usecasesValidation "chat-backend-general/internal/adaptors/validation"
 	"chat-backend-general/internal/domain"
+	"chat-backend-general/internal/infra/database"
 	usecasesFileUpload "chat-backend-general/internal/usecases"
 	usecasesMqConcrete "chat-backend-general/internal/usecases/mq"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -19,51 +26,263 @@ func NewGinServer(cfg *config.Config, logger *zap.Logger) *gin.Engine {
 	r := gin.Default()
 
 	// Middleware
-	r.Use(cors.Default())
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AddAllowHeaders("Range", "If-None-Match", "If-Range",
+		"Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset")
+	corsConfig.AddExposeHeaders("Accept-Ranges", "Content-Range", "Content-Disposition", "ETag",
+		"Location", "X-File-Id", "X-Task-Id", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Upload-Expires", "Upload-Length", "Upload-Offset")
+	r.Use(cors.New(corsConfig))
 
 	// Initialize storage adapter and file upload use case
-	storageAdapter, err := usecasesStorage.NewBlobStorageAdapter(cfg, logger)
+	fileRepository, err := usecasesStorage.NewFileRepository(cfg, logger)
 	if err != nil {
 		logger.Fatal("Error initializing storage adapter", zap.Error(err))
 	}
-	fileUploadUseCase := usecasesFileUpload.NewFileUploadUseCase(storageAdapter)
-
-	// Initialize file validators
-	fileSizeValidator := usecasesValidation.NewFileSizeValidator(10 * 1024 * 1024) // 10MB
-	fileTypeValidator := usecasesValidation.NewFileTypeValidator([]string{
-		"application/msword", "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-		"application/vnd.ms-powerpoint", "application/vnd.openxmlformats-officedocument.presentationml.presentation",
-		"application/vnd.ms-excel", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
-		"application/vnd.oasis.opendocument.text", "application/vnd.oasis.opendocument.presentation", "application/vnd.oasis.opendocument.spreadsheet",
-		"text/plain", "application/pdf",
-		"text/csv", "text/html",
-		"application/xliff+xml",
-		"text/markdown",
-		"application/vnd.ms-outlook",
-		"text/rtf",
-		"text/tab-separated-values", "text/tab-separated-values",
-	})
 
+	// Initialize file metadata repository, falling back to memory when no database is configured
+	var metadataRepository domain.FileMetadataRepository
+	var outboxRepository domain.OutboxRepository
+	if cfg.Database.Url != "" {
+		db, err := database.NewPostgresDB(cfg.Database)
+		if err != nil {
+			logger.Fatal("Error connecting to database", zap.Error(err))
+		}
+		if err := checkMigrations(db, cfg.Database.AutoMigrate, logger); err != nil {
+			logger.Fatal("Error checking database migrations", zap.Error(err))
+		}
+		metadataRepository = usecasesRepository.NewPostgresFileMetadataRepository(db)
+		outboxRepository = usecasesRepository.NewPostgresOutboxRepository(db)
+	} else {
+		logger.Warn("DATABASE_URL is not set, file metadata is kept in memory")
+		memoryRepository := usecasesRepository.NewMemoryFileMetadataRepository()
+		metadataRepository = memoryRepository
+		outboxRepository = usecasesRepository.NewMemoryOutboxRepository(memoryRepository)
+	}
+
+	// Initialize file validators from the upload policy
+	uploadPolicy, err := config.LoadUploadPolicy(cfg.Upload)
+	if err != nil {
+		logger.Fatal("Error loading upload policy", zap.Error(err))
+	}
 	fileValidators := []domain.FileValidator{
-		fileSizeValidator,
-		fileTypeValidator,
+		usecasesValidation.NewFileSizeValidator(uploadPolicy.MaxSize, uploadPolicy.TypeLimits),
+		usecasesValidation.NewFileExtensionValidator(uploadPolicy.AllowedExtensions),
+		usecasesValidation.NewFileTypeValidator(uploadPolicy.AllowedTypes),
+		usecasesValidation.NewFileContentValidator(uploadPolicy.AllowedTypes),
+		usecasesValidation.NewFileArchiveValidator(uploadPolicy.Archive.MaxEntries, uploadPolicy.Archive.MaxUncompressedSize,
+			uploadPolicy.Archive.MaxRatio, uploadPolicy.Archive.AllowMacros),
 	}
 
-	fileHandler := usecasesHttp.NewFileHandler(fileUploadUseCase, fileValidators)
+	// Initialize antivirus scanning, disabled unless a clamd address is configured
+	fileScanner, scanAction, err := newFileScanner(cfg.Scan)
+	if err != nil {
+		logger.Fatal("Error initializing antivirus scanning", zap.Error(err))
+	}
+	if fileScanner == nil {
+		logger.Warn("SCAN_CLAMD_ADDRESS is not set, uploads are not scanned for malware")
+	}
+	fileScanUseCase := usecasesFileUpload.NewFileScanUseCase(fileRepository, fileScanner, scanAction)
 
-	// Initialize Azure Service Bus adapter
-	messageQueueAdapter, err := usecasesMq.NewAzureServiceBusAdapter(cfg.ServiceBus.ConnectionString, logger)
+	// Initialize the message queue adapter of the configured broker
+	messageQueueAdapter, err := usecasesMq.NewMessageQueue(cfg, logger)
 	if err != nil {
-		logger.Fatal("Failed to initialize Azure Service Bus adapter", zap.Error(err))
+		logger.Fatal("Failed to initialize message queue adapter", zap.Error(err), zap.String("broker", cfg.Mq.Broker))
 	}
+
+	// Queue an ingestion task for stored uploads when a task name is configured, and relay
+	// the queued tasks to the message queue in the background
+	if cfg.Ingest.Task == "" {
+		logger.Warn("INGEST_TASK is not set, no ingestion task is published for uploads")
+	}
+	fileIngestUseCase := usecasesFileUpload.NewFileIngestUseCase(metadataRepository, outboxRepository, cfg.Ingest.Task, cfg.Ingest.Queue)
+	outboxRelayUseCase := usecasesFileUpload.NewOutboxRelayUseCase(outboxRepository, messageQueueAdapter,
+		cfg.Outbox.BatchSize, cfg.Outbox.Lease, cfg.Outbox.MaxBackoff)
+	if cfg.Outbox.PollInterval > 0 {
+		go runOutboxRelay(context.Background(), outboxRelayUseCase, cfg.Outbox.PollInterval, logger)
+	} else {
+		logger.Warn("OUTBOX_POLL_INTERVAL is 0, queued ingestion tasks are never published")
+	}
+
+	// Initialize quotas and the file use cases
+	tenantQuotas := make(map[string]domain.QuotaLimits, len(uploadPolicy.TenantQuotas))
+	for username, limits := range uploadPolicy.TenantQuotas {
+		tenantQuotas[username] = domain.QuotaLimits(limits)
+	}
+	quotaUseCase := usecasesFileUpload.NewQuotaUseCase(metadataRepository, domain.QuotaLimits(uploadPolicy.Quota), tenantQuotas)
+	fileUploadUseCase := usecasesFileUpload.NewFileUploadUseCase(fileRepository, metadataRepository, quotaUseCase, fileScanUseCase, fileIngestUseCase)
+	fileManagementUseCase := usecasesFileUpload.NewFileManagementUseCase(fileRepository, metadataRepository)
+	fileVersionUseCase := usecasesFileUpload.NewFileVersionUseCase(fileRepository, metadataRepository, quotaUseCase)
+	signedUploadUseCase := usecasesFileUpload.NewSignedUploadUseCase(fileRepository, metadataRepository, quotaUseCase, fileScanUseCase, fileIngestUseCase, fileValidators, cfg.Upload.SessionTtl)
+
+	fileHandler := usecasesHttp.NewFileHandler(fileUploadUseCase, fileManagementUseCase, signedUploadUseCase, fileValidators)
+	batchUploadHandler := usecasesHttp.NewBatchUploadHandler(
+		usecasesFileUpload.NewBatchUploadUseCase(fileUploadUseCase, fileValidators, cfg.Upload.BatchWorkers), cfg.Upload.BatchMaxFiles)
+	quotaHandler := usecasesHttp.NewQuotaHandler(quotaUseCase)
+	versionHandler := usecasesHttp.NewVersionHandler(fileVersionUseCase)
+
+	// Purge deleted files in the background once their retention period has passed
+	filePurgeUseCase := usecasesFileUpload.NewFilePurgeUseCase(fileRepository, metadataRepository, cfg.Retention.Period)
+	if cfg.Retention.PurgeInterval > 0 {
+		go runFilePurge(context.Background(), filePurgeUseCase, cfg.Retention.PurgeInterval, logger)
+	} else {
+		logger.Warn("RETENTION_PURGE_INTERVAL is 0, deleted files are never purged")
+	}
+
+	// Initialize resumable (tus) uploads
+	resumableUploadUseCase := usecasesFileUpload.NewResumableUploadUseCase(fileRepository,
+		usecasesRepository.NewMemoryUploadSessionStore(), metadataRepository, quotaUseCase, fileScanUseCase, fileIngestUseCase, fileValidators, cfg.Upload.ChunkSize, cfg.Upload.SessionTtl)
+	// Release the quota of uploads left pending by expired sessions, unfinalized signed
+	// uploads, or a restart
+	go runUploadExpiry(context.Background(), resumableUploadUseCase, cfg.Retention.PurgeInterval, logger)
+	tusHandler := usecasesHttp.NewTusHandler(resumableUploadUseCase, fileValidators, "/doc/uploads")
+
 	messageQueueUseCase := usecasesMqConcrete.NewMessageQueueUseCase(messageQueueAdapter)
 	messageQueueHandler := usecasesMq.NewMessageQueueHandler(messageQueueUseCase)
 
 	// Define file upload endpoint
 	r.POST("/doc/upload", fileHandler.UploadFile)
+	r.POST("/doc/upload/batch", batchUploadHandler.UploadFiles)
+
+	// Define file management endpoints
+	r.GET("/doc/files/:id", fileHandler.GetFileRecord)
+	r.GET("/doc/files/:id/versions", versionHandler.ListVersions)
+	r.POST("/doc/files/:id/restore", versionHandler.RestoreVersion)
+	r.POST("/doc/files/:id/finalize", fileHandler.FinalizeUpload)
+	r.GET("/doc/usage/:username", quotaHandler.GetUsage)
+	r.GET("/doc/:username/:chatid", fileHandler.ListFiles)
+	r.GET("/doc/:username/:chatid/:filename", fileHandler.DownloadFile)
+	r.HEAD("/doc/:username/:chatid/:filename", fileHandler.DownloadFile)
+	r.DELETE("/doc/:username/:chatid/:filename", fileHandler.DeleteFile)
+	r.POST("/doc/sign", fileHandler.SignURL)
+
+	// Define resumable upload (tus) endpoints
+	r.OPTIONS("/doc/uploads", tusHandler.Options)
+	r.POST("/doc/uploads", tusHandler.CreateUpload)
+	r.HEAD("/doc/uploads/:id", tusHandler.GetUploadOffset)
+	r.PATCH("/doc/uploads/:id", tusHandler.PatchUpload)
+	r.DELETE("/doc/uploads/:id", tusHandler.TerminateUpload)
+
+	// Serve signed URLs for storage backends that rely on this service to do so
+	if verifier, ok := fileRepository.(domain.SignedURLVerifier); ok {
+		signedStorageHandler := usecasesHttp.NewSignedStorageHandler(fileRepository, verifier, signedUploadUseCase)
+		r.GET(usecasesStorage.LocalSignedURLPrefix+"*path", signedStorageHandler.ServeSignedURL)
+		r.HEAD(usecasesStorage.LocalSignedURLPrefix+"*path", signedStorageHandler.ServeSignedURL)
+		r.PUT(usecasesStorage.LocalSignedURLPrefix+"*path", signedStorageHandler.ServeSignedURL)
+	}
 
 	// Define message queue endpoints
 	r.POST("/queue/publish", messageQueueHandler.PublishMessage)
+	r.DELETE("/queue/scheduled/:id", messageQueueHandler.CancelScheduledMessage)
 
 	return r
 }
+
+// newFileScanner returns the configured scanner and what to do with infected files. The
+// scanner is nil when scanning is disabled.
+func newFileScanner(cfg config.ScanConfig) (domain.FileScanner, domain.ScanAction, error) {
+	action := domain.ScanAction(cfg.Action)
+	if action != domain.ScanActionReject && action != domain.ScanActionQuarantine {
+		return nil, "", fmt.Errorf("invalid SCAN_ACTION %q, want %q or %q", cfg.Action, domain.ScanActionReject, domain.ScanActionQuarantine)
+	}
+	if cfg.ClamdAddress == "" {
+		return nil, action, nil
+	}
+	scanner, err := usecasesScanning.NewClamdScanner(cfg.ClamdAddress, cfg.Timeout)
+	if err != nil {
+		return nil, "", err
+	}
+	return scanner, action, nil
+}
+
+// runFilePurge purges deleted files every interval until ctx is done.
+func runFilePurge(ctx context.Context, purge usecasesFileUpload.FilePurgeUseCase, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := purge.PurgeDeletedFiles(ctx)
+		if err != nil {
+			logger.Error("Error purging deleted files", zap.Int("purged", purged), zap.Error(err))
+		} else if purged > 0 {
+			logger.Info("Purged deleted files", zap.Int("purged", purged))
+		}
+	}
+}
+
+// runUploadExpiry fails the records of expired uploads at startup, then every interval
+// until ctx is done; an interval of 0 sweeps only at startup.
+func runUploadExpiry(ctx context.Context, uploads usecasesFileUpload.ResumableUploadUseCase, interval time.Duration, logger *zap.Logger) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		failed, err := uploads.FailExpiredUploads(ctx)
+		if err != nil {
+			logger.Error("Error failing expired uploads", zap.Int("failed", failed), zap.Error(err))
+		} else if failed > 0 {
+			logger.Info("Failed expired uploads", zap.Int("failed", failed))
+		}
+
+		if tick == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		}
+	}
+}
+
+// runOutboxRelay publishes the due messages of the outbox every interval until ctx is done.
+func runOutboxRelay(ctx context.Context, relay usecasesFileUpload.OutboxRelayUseCase, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if published, err := relay.RelayMessages(ctx); err != nil {
+			logger.Error("Error relaying outbox messages", zap.Int("published", published), zap.Error(err))
+		}
+	}
+}
+
+// checkMigrations applies pending migrations when autoMigrate is set and otherwise
+// refuses to start against an outdated schema.
+func checkMigrations(db *sql.DB, autoMigrate bool, logger *zap.Logger) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if autoMigrate {
+		applied, err := migrator.Up(ctx, 0)
+		for _, migration := range applied {
+			logger.Info("Applied database migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		}
+		return err
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d database migrations are pending, run `migrate up` or set DATABASE_AUTO_MIGRATE=true", len(pending))
+	}
+	return nil
+}
@@ -1 +1,30 @@
 package storage
+
+import (
+	"chat-backend-general/config"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+// NewAzureBlobClient creates an Azure Blob Storage client authenticated with the account shared key.
+func NewAzureBlobClient(cfg config.CloudStorage) (*azblob.Client, error) {
+	if cfg.AccountName == "" || cfg.ApiKey == "" {
+		return nil, errors.New("azure storage account name and key are required")
+	}
+
+	accountURL := fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AccountName)
+
+	cred, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.ApiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shared key credential: %w", err)
+	}
+
+	client, err := azblob.NewClientWithSharedKeyCredential(accountURL, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob Storage client: %w", err)
+	}
+
+	return client, nil
+}
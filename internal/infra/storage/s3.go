@@ -1 +1,46 @@
 package storage
+
+import (
+	"chat-backend-general/config"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// NewS3Client creates a client for AWS S3 or any S3-compatible service such as MinIO.
+// AccountName is used as the access key ID and ApiKey as the secret access key.
+func NewS3Client(cfg config.CloudStorage) (*minio.Client, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("s3 endpoint is empty")
+	}
+	if cfg.AccountName == "" || cfg.ApiKey == "" {
+		return nil, errors.New("s3 access key and secret key are required")
+	}
+
+	// Accept both "host:port" and full URLs; the scheme, when present, decides TLS.
+	endpoint := cfg.Endpoint
+	secure := cfg.UseSSL
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+		}
+		endpoint = u.Host
+		secure = u.Scheme == "https"
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccountName, cfg.ApiKey, ""),
+		Secure: secure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	return client, nil
+}
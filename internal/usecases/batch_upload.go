@@ -0,0 +1,14 @@
+package usecases
+
+import (
+	"context"
+
+	"chat-backend-general/internal/domain"
+)
+
+// BatchUploadUseCase uploads several files to a chat at once, e.g. a dropped folder.
+type BatchUploadUseCase interface {
+	// HandleBatchUpload validates and uploads files concurrently and returns the outcome of
+	// each file in the order of files. A failed file does not stop the others.
+	HandleBatchUpload(ctx context.Context, files []domain.UploadedFile) []domain.BatchUploadResult
+}
@@ -0,0 +1,53 @@
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"sync"
+)
+
+type batchUploadImpl struct {
+	upload     FileUploadUseCase
+	validators []domain.FileValidator
+	workers    int
+}
+
+// NewBatchUploadUseCase creates a BatchUploadUseCase uploading at most workers files at
+// the same time through upload.
+func NewBatchUploadUseCase(upload FileUploadUseCase, validators []domain.FileValidator, workers int) BatchUploadUseCase {
+	return &batchUploadImpl{upload: upload, validators: validators, workers: max(workers, 1)}
+}
+
+// HandleBatchUpload hands the files to a pool of workers, each validating and uploading
+// one file at a time. Once ctx is done, the files not started yet fail with its error.
+func (b *batchUploadImpl) HandleBatchUpload(ctx context.Context, files []domain.UploadedFile) []domain.BatchUploadResult {
+	results := make([]domain.BatchUploadResult, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(b.workers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = b.uploadFile(ctx, files[i])
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (b *batchUploadImpl) uploadFile(ctx context.Context, file domain.UploadedFile) domain.BatchUploadResult {
+	if err := ctx.Err(); err != nil {
+		return domain.BatchUploadResult{Name: file.Name, Err: err}
+	}
+	if err := domain.ValidateFile(b.validators, file); err != nil {
+		return domain.BatchUploadResult{Name: file.Name, Err: err}
+	}
+	result, err := b.upload.HandleFileUpload(ctx, file)
+	return domain.BatchUploadResult{Name: file.Name, Result: result, Err: err}
+}
@@ -0,0 +1,108 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// concurrencyRecorder is a FileUploadUseCase recording how many uploads ran at the same time.
+type concurrencyRecorder struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (r *concurrencyRecorder) HandleFileUpload(ctx context.Context, file domain.UploadedFile) (domain.UploadResult, error) {
+	r.mu.Lock()
+	r.running++
+	r.peak = max(r.peak, r.running)
+	r.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return domain.UploadResult{FileID: "id-" + file.Name}, nil
+}
+
+// nameValidator rejects files whose name contains "bad".
+type nameValidator struct{}
+
+func (nameValidator) Validate(file domain.UploadedFile) error {
+	if strings.Contains(file.Name, "bad") {
+		return domain.NewValidationError(nil, domain.ValidationCodeInvalidFile, "filename", "bad file name", nil, file.Name)
+	}
+	return nil
+}
+
+func TestBatchUpload_BoundsConcurrency(t *testing.T) {
+	recorder := &concurrencyRecorder{}
+	batch := NewBatchUploadUseCase(recorder, nil, 3)
+
+	var files []domain.UploadedFile
+	for i := range 10 {
+		files = append(files, domain.UploadedFile{Name: fmt.Sprintf("%d.txt", i), File: strings.NewReader("x")})
+	}
+	results := batch.HandleBatchUpload(context.Background(), files)
+
+	for i, result := range results {
+		if result.Err != nil || result.Name != files[i].Name || result.Result.FileID != "id-"+files[i].Name {
+			t.Errorf("HandleBatchUpload() result %d = %+v, want the upload of %s", i, result, files[i].Name)
+		}
+	}
+	if recorder.peak > 3 || recorder.peak < 2 {
+		t.Errorf("HandleBatchUpload() ran %d uploads at once, want between 2 and 3", recorder.peak)
+	}
+}
+
+func TestBatchUpload_ReportsEachFile(t *testing.T) {
+	repo, metadata := newFakeFileRepository(), newFakeMetadataRepository()
+	upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+	batch := NewBatchUploadUseCase(upload, []domain.FileValidator{nameValidator{}}, 2)
+
+	files := []domain.UploadedFile{
+		{Name: "a.txt", File: strings.NewReader("a"), Owner: "alice", ChatID: "chat-1"},
+		{Name: "bad.txt", File: strings.NewReader("b"), Owner: "alice", ChatID: "chat-1"},
+		{Name: "c.txt", File: strings.NewReader("c"), Owner: "alice", ChatID: "../chat"},
+	}
+	results := batch.HandleBatchUpload(context.Background(), files)
+
+	var failures domain.ValidationErrors
+	if results[0].Err != nil || results[0].Result.FileID == "" {
+		t.Errorf("HandleBatchUpload() a.txt = %+v, want uploaded", results[0])
+	}
+	if !errors.As(results[1].Err, &failures) {
+		t.Errorf("HandleBatchUpload() bad.txt error = %v, want validation errors", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, domain.ErrInvalidPath) {
+		t.Errorf("HandleBatchUpload() c.txt error = %v, want %v", results[2].Err, domain.ErrInvalidPath)
+	}
+	if len(repo.data) != 1 {
+		t.Errorf("stored %d files, want 1", len(repo.data))
+	}
+}
+
+func TestBatchUpload_StopsWhenCancelled(t *testing.T) {
+	recorder := &concurrencyRecorder{}
+	batch := NewBatchUploadUseCase(recorder, nil, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	files := []domain.UploadedFile{{Name: "a.txt", File: strings.NewReader("x")}, {Name: "b.txt", File: strings.NewReader("x")}}
+	for i, result := range batch.HandleBatchUpload(ctx, files) {
+		if !errors.Is(result.Err, context.Canceled) || result.Name != files[i].Name {
+			t.Errorf("HandleBatchUpload() result %d = %+v, want %v", i, result, context.Canceled)
+		}
+	}
+	if recorder.peak != 0 {
+		t.Errorf("HandleBatchUpload() started %d uploads after cancellation, want none", recorder.peak)
+	}
+}
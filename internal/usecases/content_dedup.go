@@ -0,0 +1,35 @@
+// internal/usecases/content_dedup.go
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+)
+
+// deduplicateContent points record, a clean upload with its checksum computed, at an
+// identical file its owner stored before and deletes the upload's own copy. Otherwise the
+// checksum is attached to the stored file when the backend supports it. Both are best
+// effort: on failure the upload simply keeps its own copy, without checksum metadata.
+func deduplicateContent(ctx context.Context, fileRepository domain.FileRepository, metadataRepository domain.FileMetadataRepository, record *domain.FileRecord) {
+	original, err := metadataRepository.FindFileRecordByChecksum(ctx, record.Owner, record.Checksum)
+	if err == nil && original.ID != record.ID && original.Size == record.Size {
+		// Reference the original before checking it still exists, so that a concurrent
+		// delete of the original either sees this reference or is seen by the check. The
+		// reference stays pending: the caller stores the record along with its ingestion task.
+		shared := *record
+		shared.BlobPath = original.ContentPath()
+		reference := shared
+		reference.Status = domain.FileStatusPending
+		if metadataRepository.UpdateFileRecord(ctx, reference) == nil {
+			if _, err := fileRepository.StatFile(ctx, shared.BlobPath); err == nil {
+				_ = fileRepository.DeleteFile(context.WithoutCancel(ctx), record.StoragePath)
+				*record = shared
+				return
+			}
+		}
+	}
+
+	if recorder, ok := fileRepository.(domain.ChecksumRecorder); ok {
+		_ = recorder.RecordChecksum(ctx, record.StoragePath, record.Checksum)
+	}
+}
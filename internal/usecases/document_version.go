@@ -0,0 +1,33 @@
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"errors"
+)
+
+// maxVersionAttempts bounds the retries of createVersionedRecord when concurrent uploads
+// of the same document claim the same version.
+const maxVersionAttempts = 5
+
+// createVersionedRecord creates record as the next version of its document: the uploads
+// of record.OriginalName to record's chat.
+func createVersionedRecord(ctx context.Context, metadataRepository domain.FileMetadataRepository, record *domain.FileRecord) error {
+	var err error
+	for attempt := 0; attempt < maxVersionAttempts; attempt++ {
+		versions, listErr := metadataRepository.ListDocumentVersions(ctx, record.Owner, record.ChatID, record.OriginalName)
+		if listErr != nil {
+			return listErr
+		}
+		record.Version = 1
+		if len(versions) > 0 {
+			record.Version = versions[0].Version + 1
+		}
+
+		err = metadataRepository.CreateFileRecord(ctx, *record)
+		if !errors.Is(err, domain.ErrVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// fakeFileRepository is an in-memory domain.FileRepository used by the use case tests.
+type fakeFileRepository struct {
+	mu    sync.Mutex
+	files map[string]domain.StoredFile
+	data  map[string][]byte
+}
+
+func newFakeFileRepository() *fakeFileRepository {
+	return &fakeFileRepository{
+		files: map[string]domain.StoredFile{},
+		data:  map[string][]byte{},
+	}
+}
+
+func (r *fakeFileRepository) SaveFile(ctx context.Context, file domain.UploadedFile) error {
+	content, err := io.ReadAll(file.File)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[file.Path] = content
+	r.files[file.Path] = domain.StoredFile{FileInfo: domain.FileInfo{
+		Name:        file.Name,
+		Path:        file.Path,
+		ContentType: file.ContentType,
+		Size:        int64(len(content)),
+	}}
+	return nil
+}
+
+func (r *fakeFileRepository) StatFile(ctx context.Context, path string) (domain.FileInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.files[path]
+	if !ok {
+		return domain.FileInfo{}, domain.ErrFileNotFound
+	}
+	return stored.FileInfo, nil
+}
+
+func (r *fakeFileRepository) GetFile(ctx context.Context, path string, rng *domain.ByteRange) (domain.StoredFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.files[path]
+	if !ok {
+		return domain.StoredFile{}, domain.ErrFileNotFound
+	}
+	content := r.data[path]
+	if rng != nil {
+		content = content[rng.Offset : rng.Offset+rng.Length]
+	}
+	stored.Content = io.NopCloser(bytes.NewReader(content))
+	return stored, nil
+}
+
+func (r *fakeFileRepository) ListFiles(ctx context.Context, prefix string) ([]domain.FileInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := []domain.FileInfo{}
+	for path, stored := range r.files {
+		if strings.HasPrefix(path, prefix) {
+			files = append(files, stored.FileInfo)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (r *fakeFileRepository) DeleteFile(ctx context.Context, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.files[path]; !ok {
+		return domain.ErrFileNotFound
+	}
+	delete(r.files, path)
+	delete(r.data, path)
+	return nil
+}
+
+// fakeMetadataRepository is an in-memory domain.FileMetadataRepository used by the use case tests.
+type fakeMetadataRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.FileRecord
+}
+
+func newFakeMetadataRepository() *fakeMetadataRepository {
+	return &fakeMetadataRepository{records: map[string]domain.FileRecord{}}
+}
+
+func (r *fakeMetadataRepository) CreateFileRecord(ctx context.Context, record domain.FileRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.records {
+		if existing.Owner == record.Owner && existing.ChatID == record.ChatID &&
+			existing.OriginalName == record.OriginalName && existing.Version == record.Version {
+			return domain.ErrVersionConflict
+		}
+	}
+	r.records[record.ID] = record
+	return nil
+}
+
+func (r *fakeMetadataRepository) UpdateFileRecord(ctx context.Context, record domain.FileRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[record.ID]; !ok {
+		return domain.ErrFileRecordNotFound
+	}
+	r.records[record.ID] = record
+	return nil
+}
+
+func (r *fakeMetadataRepository) GetFileRecord(ctx context.Context, id string) (domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[id]
+	if !ok {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return record, nil
+}
+
+func (r *fakeMetadataRepository) GetFileRecordByPath(ctx context.Context, path string) (domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range r.records {
+		if record.StoragePath == path && record.Status != domain.FileStatusDeleted && record.Status != domain.FileStatusPurged {
+			return record, nil
+		}
+	}
+	return domain.FileRecord{}, domain.ErrFileRecordNotFound
+}
+
+func (r *fakeMetadataRepository) GetUsage(ctx context.Context, owner, chatID string) (domain.Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var usage domain.Usage
+	for _, record := range r.records {
+		if record.Owner == owner && (chatID == "" || record.ChatID == chatID) &&
+			(record.Status == domain.FileStatusPending || record.Status == domain.FileStatusStored) {
+			usage.Files++
+			usage.Bytes += record.Size
+		}
+	}
+	return usage, nil
+}
+
+func (r *fakeMetadataRepository) ListFileRecords(ctx context.Context, owner, chatID string) ([]domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := []domain.FileRecord{}
+	for _, record := range r.records {
+		if record.Owner == owner && record.ChatID == chatID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	return records, nil
+}
+
+func (r *fakeMetadataRepository) FindFileRecordByChecksum(ctx context.Context, owner, checksum string) (domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found *domain.FileRecord
+	for _, record := range r.records {
+		if record.Owner == owner && record.Checksum == checksum && record.Status == domain.FileStatusStored &&
+			(found == nil || record.CreatedAt.Before(found.CreatedAt)) {
+			found = &record
+		}
+	}
+	if found == nil {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return *found, nil
+}
+
+func (r *fakeMetadataRepository) CountContentReferences(ctx context.Context, path string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, record := range r.records {
+		if record.ContentPath() == path && (record.Status == domain.FileStatusPending || record.Status == domain.FileStatusStored ||
+			record.Status == domain.FileStatusDeleted) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeMetadataRepository) ListDocumentVersions(ctx context.Context, owner, chatID, name string) ([]domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := []domain.FileRecord{}
+	for _, record := range r.records {
+		if record.Owner == owner && record.ChatID == chatID && record.OriginalName == name {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Version > records[j].Version })
+	return records, nil
+}
+
+func (r *fakeMetadataRepository) ListPurgeableFileRecords(ctx context.Context, deletedBy time.Time, limit int) ([]domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := []domain.FileRecord{}
+	for _, record := range r.records {
+		if record.Status == domain.FileStatusDeleted && !record.DeletedAt.After(deletedBy) && len(records) < limit {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (r *fakeMetadataRepository) ListStalePendingFileRecords(ctx context.Context, createdBy time.Time, limit int) ([]domain.FileRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := []domain.FileRecord{}
+	for _, record := range r.records {
+		if record.Status == domain.FileStatusPending && !record.CreatedAt.After(createdBy) && len(records) < limit {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// fakeOutboxRepository is an in-memory domain.OutboxRepository saving file records in
+// metadata. Saving fails with err when it is set, leaving both the record and the outbox unchanged.
+type fakeOutboxRepository struct {
+	mu       sync.Mutex
+	metadata *fakeMetadataRepository
+	err      error
+	messages map[string]domain.OutboxMessage
+}
+
+func newFakeOutboxRepository(metadata *fakeMetadataRepository) *fakeOutboxRepository {
+	return &fakeOutboxRepository{metadata: metadata, messages: map[string]domain.OutboxMessage{}}
+}
+
+func (o *fakeOutboxRepository) UpdateFileRecordWithMessage(ctx context.Context, record domain.FileRecord, message domain.OutboxMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.err != nil {
+		return o.err
+	}
+	if err := o.metadata.UpdateFileRecord(ctx, record); err != nil {
+		return err
+	}
+	o.messages[message.ID] = message
+	return nil
+}
+
+func (o *fakeOutboxRepository) ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	due := []domain.OutboxMessage{}
+	for _, message := range o.messages {
+		if len(due) < limit && !message.NextAttemptAt.After(now) {
+			message.Attempts++
+			message.NextAttemptAt = now.Add(lease)
+			o.messages[message.ID] = message
+			due = append(due, message)
+		}
+	}
+	return due, nil
+}
+
+func (o *fakeOutboxRepository) RetryOutboxMessage(ctx context.Context, id string, nextAttemptAt time.Time, lastError string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	message, ok := o.messages[id]
+	if !ok {
+		return domain.ErrOutboxMessageNotFound
+	}
+	message.NextAttemptAt, message.LastError = nextAttemptAt, lastError
+	o.messages[id] = message
+	return nil
+}
+
+func (o *fakeOutboxRepository) DeleteOutboxMessage(ctx context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.messages[id]; !ok {
+		return domain.ErrOutboxMessageNotFound
+	}
+	delete(o.messages, id)
+	return nil
+}
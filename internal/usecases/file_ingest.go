@@ -0,0 +1,15 @@
+package usecases
+
+import (
+	"context"
+
+	"chat-backend-general/internal/domain"
+)
+
+// FileIngestUseCase starts the processing of stored uploads by a Celery worker.
+type FileIngestUseCase interface {
+	// IngestFile saves record, a stored upload, and adds its ingestion task to the outbox
+	// in the same transaction, then returns the task ID. Only record is saved, and the ID
+	// is empty, when ingestion is disabled.
+	IngestFile(ctx context.Context, record domain.FileRecord) (string, error)
+}
@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"time"
+)
+
+type fileIngestImpl struct {
+	metadataRepository domain.FileMetadataRepository
+	outbox             domain.OutboxRepository
+	task               string
+	queueName          string
+}
+
+// NewFileIngestUseCase creates a FileIngestUseCase queueing task for queueName in outbox,
+// from where an OutboxRelayUseCase publishes it. Ingestion is disabled when task is empty.
+func NewFileIngestUseCase(metadataRepository domain.FileMetadataRepository, outbox domain.OutboxRepository, task, queueName string) FileIngestUseCase {
+	return &fileIngestImpl{metadataRepository: metadataRepository, outbox: outbox, task: task, queueName: queueName}
+}
+
+// IngestFile queues task with the file's ID, storage path, content type and owner as
+// keyword arguments. blob_path is where the content can be read from, which differs
+// from path for deduplicated files.
+func (i *fileIngestImpl) IngestFile(ctx context.Context, record domain.FileRecord) (string, error) {
+	if i.task == "" {
+		return "", i.metadataRepository.UpdateFileRecord(ctx, record)
+	}
+
+	message := domain.NewCeleryMessage(i.task, []interface{}{}, map[string]interface{}{
+		"file_id":      record.ID,
+		"path":         record.StoragePath,
+		"blob_path":    record.ContentPath(),
+		"content_type": record.ContentType,
+		"owner":        record.Owner,
+		"chat_id":      record.ChatID,
+	})
+	now := time.Now().UTC()
+	err := i.outbox.UpdateFileRecordWithMessage(ctx, record, domain.OutboxMessage{
+		ID:            message.ID,
+		Queue:         i.queueName,
+		Message:       message,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return message.ID, nil
+}
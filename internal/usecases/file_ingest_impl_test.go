@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+// fakeMessageQueue records published messages and fails when err is set.
+type fakeMessageQueue struct {
+	mu       sync.Mutex
+	err      error
+	queues   []string
+	messages []domain.CeleryMessage
+}
+
+func (q *fakeMessageQueue) PublishMessage(queueName string, message domain.CeleryMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	q.queues = append(q.queues, queueName)
+	q.messages = append(q.messages, message)
+	return nil
+}
+
+func TestFileIngest_IngestFile(t *testing.T) {
+	record := domain.FileRecord{
+		ID: "file-1", Owner: "alice", ChatID: "chat-1", ContentType: "application/pdf", Status: domain.FileStatusStored,
+		StoragePath: "alice/chat-1/file-1_a.pdf", BlobPath: "alice/chat-0/file-0_a.pdf",
+	}
+
+	tests := []struct {
+		name   string
+		task   string
+		err    error
+		queued bool
+	}{
+		{name: "disabled without task"},
+		{name: "queued", task: "tasks.ingest_document", queued: true},
+		{name: "outbox failure", task: "tasks.ingest_document", err: errors.New("database down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := newFakeMetadataRepository()
+			metadata.records[record.ID] = domain.FileRecord{ID: record.ID, Status: domain.FileStatusPending}
+			outbox := newFakeOutboxRepository(metadata)
+			outbox.err = tt.err
+
+			taskID, err := NewFileIngestUseCase(metadata, outbox, tt.task, "ingest").IngestFile(context.Background(), record)
+			if (err != nil) != (tt.err != nil) {
+				t.Fatalf("IngestFile() error = %v, want error %v", err, tt.err)
+			}
+			if stored := metadata.records[record.ID].Status == domain.FileStatusStored; stored != (tt.err == nil) {
+				t.Errorf("record status = %s, want saved %v", metadata.records[record.ID].Status, tt.err == nil)
+			}
+			if !tt.queued {
+				if taskID != "" || len(outbox.messages) != 0 {
+					t.Errorf("IngestFile() = %q with %d messages, want nothing queued", taskID, len(outbox.messages))
+				}
+				return
+			}
+
+			queued, ok := outbox.messages[taskID]
+			if len(outbox.messages) != 1 || !ok || queued.Queue != "ingest" {
+				t.Fatalf("queued %v, want one message %s to ingest", outbox.messages, taskID)
+			}
+			message := queued.Message
+			if message.ID != taskID || message.Task != tt.task || queued.NextAttemptAt.IsZero() {
+				t.Errorf("queued %+v, want task %s with ID %s, due now", queued, tt.task, taskID)
+			}
+			want := map[string]interface{}{
+				"file_id": "file-1", "path": record.StoragePath, "blob_path": record.BlobPath,
+				"content_type": "application/pdf", "owner": "alice", "chat_id": "chat-1",
+			}
+			for key, value := range want {
+				if message.Kwargs[key] != value {
+					t.Errorf("kwargs[%s] = %v, want %v", key, message.Kwargs[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestFileUpload_QueuesIngestionTask(t *testing.T) {
+	repo, metadata := newFakeFileRepository(), newFakeMetadataRepository()
+	outbox := newFakeOutboxRepository(metadata)
+	upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, nil, ""),
+		NewFileIngestUseCase(metadata, outbox, "tasks.ingest_document", "default"))
+
+	result, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+		Name: "a.pdf", File: strings.NewReader("%PDF-1.7"), Owner: "alice", ChatID: "chat-1",
+	})
+	if err != nil {
+		t.Fatalf("HandleFileUpload() error = %v", err)
+	}
+	queued, ok := outbox.messages[result.TaskID]
+	if len(outbox.messages) != 1 || !ok || queued.Message.Kwargs["file_id"] != result.FileID {
+		t.Errorf("HandleFileUpload() = %+v, queued %+v, want the task of the file", result, outbox.messages)
+	}
+
+	// Without the task the upload is not stored, so that no stored file misses its task.
+	outbox.err = errors.New("database down")
+	if result, err = upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+		Name: "b.pdf", File: strings.NewReader("%PDF-1.7"), Owner: "alice", ChatID: "chat-1",
+	}); err == nil {
+		t.Errorf("HandleFileUpload() with database down = %+v, want error", result)
+	}
+	for _, record := range metadata.records {
+		if record.OriginalName == "b.pdf" && record.Status == domain.FileStatusStored {
+			t.Errorf("record %+v stored without its ingestion task", record)
+		}
+	}
+}
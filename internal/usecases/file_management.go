@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// FileManagementUseCase covers reading back and removing the files of a chat.
+type FileManagementUseCase interface {
+	ListFiles(ctx context.Context, username, chatID string) ([]domain.FileInfo, error)
+	StatFile(ctx context.Context, username, chatID, filename string) (domain.FileInfo, error)
+	GetFile(ctx context.Context, username, chatID, filename string, rng *domain.ByteRange) (domain.StoredFile, error)
+	DeleteFile(ctx context.Context, username, chatID, filename string) error
+	GetFileRecord(ctx context.Context, id string) (domain.FileRecord, error)
+	// SignURL returns a signed download URL; uploads are signed by SignedUploadUseCase.
+	SignURL(ctx context.Context, username, chatID, filename string, expiry time.Duration) (domain.SignedURL, error)
+}
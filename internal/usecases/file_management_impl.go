@@ -0,0 +1,208 @@
+// internal/usecases/file_management_impl.go
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"errors"
+	pathpkg "path"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fileManagementImpl struct {
+	fileRepository     domain.FileRepository
+	metadataRepository domain.FileMetadataRepository
+}
+
+func NewFileManagementUseCase(fileRepository domain.FileRepository, metadataRepository domain.FileMetadataRepository) FileManagementUseCase {
+	return &fileManagementImpl{fileRepository: fileRepository, metadataRepository: metadataRepository}
+}
+
+// ListFiles returns the current version of each document of username's chat, the newest
+// stored one, and the files stored under username/chatID/ without metadata.
+func (f *fileManagementImpl) ListFiles(ctx context.Context, username, chatID string) ([]domain.FileInfo, error) {
+	prefix, err := storagePath(username, chatID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := f.fileRepository.ListFiles(ctx, prefix+"/")
+	if err != nil {
+		return nil, err
+	}
+	records, err := f.metadataRepository.ListFileRecords(ctx, username, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]domain.FileRecord, len(records))
+	current := make(map[string]domain.FileRecord)
+	for _, record := range records {
+		byPath[record.StoragePath] = record
+		if record.Status == domain.FileStatusStored && record.Version > current[record.OriginalName].Version {
+			current[record.OriginalName] = record
+		}
+	}
+	files := make([]domain.FileInfo, 0, len(current))
+	listed := make(map[string]bool, len(current))
+	for _, info := range stored {
+		// Older versions, and deleted files whose content is kept for restoring, are hidden.
+		if record, ok := byPath[info.Path]; ok && current[record.OriginalName].ID != record.ID {
+			continue
+		}
+		files = append(files, info)
+		listed[info.Path] = true
+	}
+	// Deduplicated files have no content of their own under the chat's prefix.
+	for _, record := range current {
+		if !listed[record.StoragePath] {
+			files = append(files, domain.FileInfo{
+				Name:         pathpkg.Base(record.StoragePath),
+				Path:         record.StoragePath,
+				ContentType:  record.ContentType,
+				Size:         record.Size,
+				LastModified: record.UpdatedAt,
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// StatFile returns the metadata of username/chatID/filename.
+func (f *fileManagementImpl) StatFile(ctx context.Context, username, chatID, filename string) (domain.FileInfo, error) {
+	path, err := storagePath(username, chatID, filename)
+	if err != nil {
+		return domain.FileInfo{}, err
+	}
+	contentPath, err := f.contentPath(ctx, path)
+	if err != nil {
+		return domain.FileInfo{}, err
+	}
+	info, err := f.fileRepository.StatFile(ctx, contentPath)
+	if err != nil {
+		return domain.FileInfo{}, err
+	}
+	info.Name, info.Path = filename, path
+	return info, nil
+}
+
+// GetFile opens username/chatID/filename, or rng of it, for streaming.
+func (f *fileManagementImpl) GetFile(ctx context.Context, username, chatID, filename string, rng *domain.ByteRange) (domain.StoredFile, error) {
+	path, err := storagePath(username, chatID, filename)
+	if err != nil {
+		return domain.StoredFile{}, err
+	}
+	contentPath, err := f.contentPath(ctx, path)
+	if err != nil {
+		return domain.StoredFile{}, err
+	}
+	stored, err := f.fileRepository.GetFile(ctx, contentPath, rng)
+	if err != nil {
+		return domain.StoredFile{}, err
+	}
+	stored.Name, stored.Path = filename, path
+	return stored, nil
+}
+
+// DeleteFile soft deletes the document username/chatID/filename belongs to: every stored
+// version of it is marked deleted, which releases its share of the owner's quota. The
+// content is kept for the retention period, see FilePurgeUseCase.
+func (f *fileManagementImpl) DeleteFile(ctx context.Context, username, chatID, filename string) error {
+	path, err := storagePath(username, chatID, filename)
+	if err != nil {
+		return err
+	}
+
+	record, err := f.metadataRepository.GetFileRecordByPath(ctx, path)
+	if errors.Is(err, domain.ErrFileRecordNotFound) {
+		contentPath, err := f.contentPath(ctx, path)
+		if err != nil {
+			return err
+		}
+		return f.fileRepository.DeleteFile(ctx, contentPath) // Stored before metadata was recorded
+	}
+	if err != nil {
+		return err
+	}
+	versions, err := f.metadataRepository.ListDocumentVersions(ctx, record.Owner, record.ChatID, record.OriginalName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, version := range versions {
+		if version.ID != record.ID && version.Status != domain.FileStatusStored {
+			continue
+		}
+		version.Status = domain.FileStatusDeleted
+		version.UpdatedAt, version.DeletedAt = now, &now
+		if err := f.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contentPath returns the storage key holding the content of the file at path, which
+// differs from path for deduplicated uploads. The content of deleted files, kept until
+// it is purged, is not found under path, nor is that of files which are not stored or
+// were found infected.
+func (f *fileManagementImpl) contentPath(ctx context.Context, path string) (string, error) {
+	record, err := f.metadataRepository.GetFileRecordByPath(ctx, path)
+	if err == nil {
+		if record.ScanVerdict == domain.ScanVerdictInfected {
+			return "", domain.ErrFileInfected
+		}
+		if record.Status != domain.FileStatusStored {
+			return "", domain.ErrFileNotFound
+		}
+		return record.ContentPath(), nil
+	}
+	if !errors.Is(err, domain.ErrFileRecordNotFound) {
+		return "", err
+	}
+
+	references, err := f.metadataRepository.CountContentReferences(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if references > 0 {
+		return "", domain.ErrFileNotFound
+	}
+	return path, nil
+}
+
+// GetFileRecord returns the metadata of a file by its ID.
+func (f *fileManagementImpl) GetFileRecord(ctx context.Context, id string) (domain.FileRecord, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return f.metadataRepository.GetFileRecord(ctx, id)
+}
+
+// SignURL returns a signed download URL for username/chatID/filename when the storage
+// backend supports it and the file is stored and clean.
+func (f *fileManagementImpl) SignURL(ctx context.Context, username, chatID, filename string, expiry time.Duration) (domain.SignedURL, error) {
+	signer, ok := f.fileRepository.(domain.URLSigner)
+	if !ok {
+		return domain.SignedURL{}, domain.ErrNotSupported
+	}
+
+	path, err := storagePath(username, chatID, filename)
+	if err != nil {
+		return domain.SignedURL{}, err
+	}
+	if _, err := f.contentPath(ctx, path); err != nil {
+		return domain.SignedURL{}, err
+	}
+
+	signed, err := signer.SignURL(ctx, path, domain.SignedURLDownload, expiry, 0)
+	if err != nil {
+		return domain.SignedURL{}, err
+	}
+	signed.Path = path
+	return signed, nil
+}
@@ -0,0 +1,76 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestFileManagement_ListAndDelete(t *testing.T) {
+	repo := newFakeFileRepository()
+	metadata := newFakeMetadataRepository()
+	ctx := context.Background()
+	upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+	manage := NewFileManagementUseCase(repo, metadata)
+
+	var stored domain.UploadResult
+	for _, file := range []domain.UploadedFile{
+		{Owner: "alice", ChatID: "chat-1", Name: "a.pdf"},
+		{Owner: "alice", ChatID: "chat-10", Name: "b.pdf"},
+		{Owner: "bob", ChatID: "chat-1", Name: "c.pdf"},
+	} {
+		file.File = strings.NewReader("x")
+		result, err := upload.HandleFileUpload(ctx, file)
+		if err != nil {
+			t.Fatalf("HandleFileUpload(%q) error = %v", file.Name, err)
+		}
+		if file.Name == "a.pdf" {
+			stored = result
+		}
+	}
+
+	files, err := manage.ListFiles(ctx, "alice", "chat-1")
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Path != stored.Path {
+		t.Errorf("ListFiles(alice, chat-1) = %+v, want only %s", files, stored.Path)
+	}
+
+	name := stored.FileID + "_a.pdf"
+	if err := manage.DeleteFile(ctx, "alice", "chat-1", name); err != nil {
+		t.Fatalf("DeleteFile() error = %v", err)
+	}
+	if err := manage.DeleteFile(ctx, "alice", "chat-1", name); !errors.Is(err, domain.ErrFileNotFound) {
+		t.Errorf("DeleteFile() on deleted file error = %v, want %v", err, domain.ErrFileNotFound)
+	}
+}
+
+func TestFileManagement_RejectsInvalidSegments(t *testing.T) {
+	manage := NewFileManagementUseCase(newFakeFileRepository(), newFakeMetadataRepository())
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		username string
+		chatID   string
+		filename string
+	}{
+		{name: "empty username", username: "", chatID: "chat-1", filename: "a.pdf"},
+		{name: "parent directory", username: "alice", chatID: "..", filename: "a.pdf"},
+		{name: "nested separator", username: "alice", chatID: "chat-1", filename: "../bob/a.pdf"},
+		{name: "backslash", username: "alice", chatID: "chat-1", filename: `..\\a.pdf`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manage.DeleteFile(ctx, tt.username, tt.chatID, tt.filename)
+			if !errors.Is(err, domain.ErrInvalidPath) {
+				t.Errorf("DeleteFile(%q, %q, %q) error = %v, want %v", tt.username, tt.chatID, tt.filename, err, domain.ErrInvalidPath)
+			}
+		})
+	}
+}
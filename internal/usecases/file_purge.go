@@ -0,0 +1,12 @@
+package usecases
+
+import (
+	"context"
+)
+
+// FilePurgeUseCase removes the content of deleted files once they can no longer be restored.
+type FilePurgeUseCase interface {
+	// PurgeDeletedFiles purges the files deleted longer than the retention period ago and
+	// returns how many were purged.
+	PurgeDeletedFiles(ctx context.Context) (int, error)
+}
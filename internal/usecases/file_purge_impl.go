@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"errors"
+	"time"
+)
+
+// purgeBatchSize is the number of deleted records loaded at once while purging.
+const purgeBatchSize = 100
+
+type filePurgeImpl struct {
+	fileRepository     domain.FileRepository
+	metadataRepository domain.FileMetadataRepository
+	retention          time.Duration
+}
+
+func NewFilePurgeUseCase(fileRepository domain.FileRepository, metadataRepository domain.FileMetadataRepository, retention time.Duration) FilePurgeUseCase {
+	return &filePurgeImpl{fileRepository: fileRepository, metadataRepository: metadataRepository, retention: retention}
+}
+
+// PurgeDeletedFiles marks every file deleted before the retention period purged and
+// removes its content unless other files share it.
+func (p *filePurgeImpl) PurgeDeletedFiles(ctx context.Context) (int, error) {
+	deletedBy := time.Now().UTC().Add(-p.retention)
+	purged := 0
+	for {
+		records, err := p.metadataRepository.ListPurgeableFileRecords(ctx, deletedBy, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, record := range records {
+			if err := p.purge(ctx, record); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(records) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (p *filePurgeImpl) purge(ctx context.Context, record domain.FileRecord) error {
+	record.Status = domain.FileStatusPurged
+	record.UpdatedAt = time.Now().UTC()
+	if err := p.metadataRepository.UpdateFileRecord(ctx, record); err != nil {
+		return err
+	}
+	references, err := p.metadataRepository.CountContentReferences(ctx, record.ContentPath())
+	if err != nil || references > 0 {
+		return err
+	}
+	err = p.fileRepository.DeleteFile(context.WithoutCancel(ctx), record.ContentPath())
+	if errors.Is(err, domain.ErrFileNotFound) {
+		return nil // Content already gone, e.g. after a failed upload
+	}
+	return err
+}
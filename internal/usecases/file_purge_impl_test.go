@@ -0,0 +1,43 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestFilePurge_PurgesAfterRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		purged    int
+		status    domain.FileStatus
+	}{
+		{name: "within retention", retention: time.Hour, purged: 0, status: domain.FileStatusDeleted},
+		{name: "retention passed", retention: 0, purged: 3, status: domain.FileStatusPurged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, metadata, results := versionFixture(t)
+			ctx := context.Background()
+			if err := NewFileManagementUseCase(repo, metadata).DeleteFile(ctx, "alice", "chat-1", results[0].Path[len("alice/chat-1/"):]); err != nil {
+				t.Fatalf("DeleteFile() error = %v", err)
+			}
+
+			purged, err := NewFilePurgeUseCase(repo, metadata, tt.retention).PurgeDeletedFiles(ctx)
+			if err != nil || purged != tt.purged {
+				t.Fatalf("PurgeDeletedFiles() = %d, %v, want %d", purged, err, tt.purged)
+			}
+			for _, result := range results {
+				record, _ := metadata.GetFileRecord(ctx, result.FileID)
+				_, kept := repo.data[result.Path]
+				if record.Status != tt.status || kept != (tt.status == domain.FileStatusDeleted) {
+					t.Errorf("version %d = %s, content kept %v, want %s", record.Version, record.Status, kept, tt.status)
+				}
+			}
+		})
+	}
+}
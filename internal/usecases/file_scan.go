@@ -0,0 +1,16 @@
+package usecases
+
+import (
+	"context"
+
+	"chat-backend-general/internal/domain"
+)
+
+// FileScanUseCase scans stored uploads for malware and contains infected ones.
+type FileScanUseCase interface {
+	// ScanFile scans the stored content of record and records the verdict on it. Infected
+	// content is deleted or quarantined, per the configured action, and domain.ErrFileInfected
+	// returned; content that cannot be scanned is deleted and domain.ErrScanFailed returned.
+	// Persisting record is left to the caller.
+	ScanFile(ctx context.Context, record *domain.FileRecord) error
+}
@@ -0,0 +1,97 @@
+// internal/usecases/file_scan_impl.go
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"fmt"
+	"time"
+)
+
+type fileScanImpl struct {
+	fileRepository domain.FileRepository
+	scanner        domain.FileScanner
+	action         domain.ScanAction
+}
+
+// NewFileScanUseCase creates a FileScanUseCase. A nil scanner disables scanning, leaving
+// records without a verdict.
+func NewFileScanUseCase(fileRepository domain.FileRepository, scanner domain.FileScanner, action domain.ScanAction) FileScanUseCase {
+	return &fileScanImpl{fileRepository: fileRepository, scanner: scanner, action: action}
+}
+
+func (s *fileScanImpl) ScanFile(ctx context.Context, record *domain.FileRecord) error {
+	if s.scanner == nil {
+		return nil
+	}
+
+	result, err := s.scan(ctx, record.StoragePath)
+	if err != nil {
+		// Fail closed: content that was not scanned is never served. The cause is not
+		// returned, as it describes the scanner's infrastructure rather than the upload.
+		_ = s.fileRepository.DeleteFile(context.WithoutCancel(ctx), record.StoragePath)
+		record.Status = domain.FileStatusFailed
+		record.UpdatedAt = time.Now().UTC()
+		return domain.ErrScanFailed
+	}
+
+	now := time.Now().UTC()
+	record.ScanVerdict = result.Verdict
+	record.ScanSignature = result.Signature
+	record.ScannedAt = &now
+	record.UpdatedAt = now
+	if result.Verdict != domain.ScanVerdictInfected {
+		return nil
+	}
+
+	// Rejected before the content is moved or deleted, so that an infected file whose
+	// content could not be removed is still never served.
+	record.Status = domain.FileStatusRejected
+	if s.action == domain.ScanActionQuarantine {
+		path, err := s.quarantine(ctx, record)
+		if err != nil {
+			return err
+		}
+		record.StoragePath = path
+		record.Status = domain.FileStatusQuarantined
+	} else if err := s.fileRepository.DeleteFile(ctx, record.StoragePath); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", domain.ErrFileInfected, result.Signature)
+}
+
+func (s *fileScanImpl) scan(ctx context.Context, path string) (domain.ScanResult, error) {
+	stored, err := s.fileRepository.GetFile(ctx, path, nil)
+	if err != nil {
+		return domain.ScanResult{}, err
+	}
+	defer stored.Content.Close()
+	return s.scanner.Scan(ctx, stored.Content)
+}
+
+// quarantine moves the content of record under quarantinePrefix and returns its new path.
+func (s *fileScanImpl) quarantine(ctx context.Context, record *domain.FileRecord) (string, error) {
+	stored, err := s.fileRepository.GetFile(ctx, record.StoragePath, nil)
+	if err != nil {
+		return "", err
+	}
+	defer stored.Content.Close()
+
+	path := quarantinePrefix + "/" + record.StoragePath
+	err = s.fileRepository.SaveFile(ctx, domain.UploadedFile{
+		Name:        record.OriginalName,
+		ContentType: stored.ContentType,
+		File:        stored.Content,
+		Size:        stored.Size,
+		Path:        path,
+		Owner:       record.Owner,
+		ChatID:      record.ChatID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.fileRepository.DeleteFile(ctx, record.StoragePath); err != nil {
+		return "", err
+	}
+	return path, nil
+}
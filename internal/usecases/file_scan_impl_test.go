@@ -0,0 +1,129 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+// fakeScanner flags content containing "virus" and fails when err is set.
+type fakeScanner struct {
+	err error
+}
+
+func (s fakeScanner) Scan(ctx context.Context, content io.Reader) (domain.ScanResult, error) {
+	if s.err != nil {
+		return domain.ScanResult{}, s.err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return domain.ScanResult{}, err
+	}
+	if strings.Contains(string(data), "virus") {
+		return domain.ScanResult{Verdict: domain.ScanVerdictInfected, Signature: "Test-Virus"}, nil
+	}
+	return domain.ScanResult{Verdict: domain.ScanVerdictClean}, nil
+}
+
+func TestFileUpload_ScansContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		scanner     fakeScanner
+		action      domain.ScanAction
+		err         error
+		status      domain.FileStatus
+		verdict     domain.ScanVerdict
+		quarantined bool
+	}{
+		{name: "clean", content: "hello", action: domain.ScanActionReject, status: domain.FileStatusStored, verdict: domain.ScanVerdictClean},
+		{name: "infected rejected", content: "a virus", action: domain.ScanActionReject, err: domain.ErrFileInfected, status: domain.FileStatusRejected, verdict: domain.ScanVerdictInfected},
+		{name: "infected quarantined", content: "a virus", action: domain.ScanActionQuarantine, err: domain.ErrFileInfected, status: domain.FileStatusQuarantined, verdict: domain.ScanVerdictInfected, quarantined: true},
+		{name: "scanner unavailable", content: "hello", scanner: fakeScanner{err: errors.New("connection refused")}, action: domain.ScanActionReject, err: domain.ErrScanFailed, status: domain.FileStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, metadata := newFakeFileRepository(), newFakeMetadataRepository()
+			upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, tt.scanner, tt.action), NewFileIngestUseCase(metadata, nil, "", ""))
+
+			_, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+				Name: "notes.txt", File: strings.NewReader(tt.content), Owner: "alice", ChatID: "chat-1",
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("HandleFileUpload() error = %v, want %v", err, tt.err)
+			}
+
+			if len(metadata.records) != 1 {
+				t.Fatalf("got %d records, want 1", len(metadata.records))
+			}
+			for _, record := range metadata.records {
+				if record.Status != tt.status || record.ScanVerdict != tt.verdict {
+					t.Errorf("record status, verdict = %q, %q, want %q, %q", record.Status, record.ScanVerdict, tt.status, tt.verdict)
+				}
+				if tt.verdict != "" && record.ScannedAt == nil {
+					t.Error("record ScannedAt = nil, want scan time")
+				}
+				if got := strings.HasPrefix(record.StoragePath, quarantinePrefix+"/"); got != tt.quarantined {
+					t.Errorf("record path = %q, quarantined %v, want %v", record.StoragePath, got, tt.quarantined)
+				}
+
+				// Only clean files stay at their path; quarantined ones move along with the record.
+				_, statErr := repo.StatFile(context.Background(), record.StoragePath)
+				if stored := statErr == nil; stored != (tt.err == nil || tt.quarantined) {
+					t.Errorf("StatFile(%q) error = %v, want stored %v", record.StoragePath, statErr, !stored)
+				}
+			}
+		})
+	}
+}
+
+func TestFileScan_DisabledWithoutScanner(t *testing.T) {
+	record := domain.FileRecord{StoragePath: "alice/chat-1/notes.txt", Status: domain.FileStatusStored}
+	if err := NewFileScanUseCase(newFakeFileRepository(), nil, domain.ScanActionReject).ScanFile(context.Background(), &record); err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if record.ScanVerdict != "" || record.Status != domain.FileStatusStored {
+		t.Errorf("ScanFile() record = %+v, want it unchanged", record)
+	}
+}
+
+// undeletableFileRepository is a fakeFileRepository that cannot delete content.
+type undeletableFileRepository struct {
+	*fakeFileRepository
+}
+
+func (r undeletableFileRepository) DeleteFile(ctx context.Context, path string) error {
+	return errors.New("storage unavailable")
+}
+
+func TestFileUpload_InfectedFileIsNeverServed(t *testing.T) {
+	for _, action := range []domain.ScanAction{domain.ScanActionReject, domain.ScanActionQuarantine} {
+		t.Run(string(action), func(t *testing.T) {
+			repo, metadata := undeletableFileRepository{newFakeFileRepository()}, newFakeMetadataRepository()
+			upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, fakeScanner{}, action), NewFileIngestUseCase(metadata, nil, "", ""))
+
+			_, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+				Name: "notes.txt", File: strings.NewReader("a virus"), Owner: "alice", ChatID: "chat-1",
+			})
+			if err == nil {
+				t.Fatal("HandleFileUpload() error = nil, want the delete error")
+			}
+
+			for _, record := range metadata.records {
+				if record.Status != domain.FileStatusRejected {
+					t.Errorf("record status = %q, want %q", record.Status, domain.FileStatusRejected)
+				}
+				name := strings.TrimPrefix(record.StoragePath, "alice/chat-1/")
+				_, err := NewFileManagementUseCase(repo, metadata).GetFile(context.Background(), "alice", "chat-1", name, nil)
+				if !errors.Is(err, domain.ErrFileInfected) {
+					t.Errorf("GetFile(%q) error = %v, want %v", name, err, domain.ErrFileInfected)
+				}
+			}
+		})
+	}
+}
@@ -7,5 +7,5 @@ import (
 )
 
 type FileUploadUseCase interface {
-	HandleFileUpload(ctx context.Context, file domain.UploadedFile) error
+	HandleFileUpload(ctx context.Context, file domain.UploadedFile) (domain.UploadResult, error)
 }
@@ -2,20 +2,124 @@
 package usecases
 
 import (
-	"chat-backend-general/internal/adaptors/storage"
 	"chat-backend-general/internal/domain"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type fileUploadImpl struct {
-	storageAdapter *storage.BlobStorageAdapter
+	fileRepository     domain.FileRepository
+	metadataRepository domain.FileMetadataRepository
+	quota              QuotaUseCase
+	scan               FileScanUseCase
+	ingest             FileIngestUseCase
+}
+
+func NewFileUploadUseCase(fileRepository domain.FileRepository, metadataRepository domain.FileMetadataRepository, quota QuotaUseCase, scan FileScanUseCase, ingest FileIngestUseCase) FileUploadUseCase {
+	return &fileUploadImpl{fileRepository: fileRepository, metadataRepository: metadataRepository, quota: quota, scan: scan, ingest: ingest}
+}
+
+// HandleFileUpload records the file's metadata as the next version of the document named
+// file.Name in the chat, checks the owner's quota, stores the content under a new
+// owner/chatID key, verifies its checksum when the client sent one, scans it and returns
+// the new file ID, along with the ID of the ingestion task queued for it. Content the
+// owner already stored is kept only once. The record stays in the failed state when the
+// quota is exceeded, storing the content fails or the checksum does not match, and
+// records the scan outcome when the content is infected.
+func (f *fileUploadImpl) HandleFileUpload(ctx context.Context, file domain.UploadedFile) (domain.UploadResult, error) {
+	record, err := newFileRecord(file)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	if err := createVersionedRecord(ctx, f.metadataRepository, &record); err != nil {
+		return domain.UploadResult{}, err
+	}
+	if err := f.quota.CheckQuota(ctx, file.Owner, file.ChatID); err != nil {
+		f.markFailed(ctx, record)
+		return domain.UploadResult{}, err
+	}
+	file.Path = record.StoragePath
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	file.File = io.TeeReader(file.File, io.MultiWriter(hash, counter))
+
+	if err := f.fileRepository.SaveFile(ctx, file); err != nil {
+		f.markFailed(ctx, record)
+		return domain.UploadResult{}, err
+	}
+
+	record.Size = counter.n
+	record.Checksum = hex.EncodeToString(hash.Sum(nil))
+	if file.Checksum != "" && !strings.EqualFold(file.Checksum, record.Checksum) {
+		_ = f.fileRepository.DeleteFile(context.WithoutCancel(ctx), record.StoragePath)
+		f.markFailed(ctx, record)
+		return domain.UploadResult{}, domain.ErrChecksumMismatch
+	}
+
+	record.Status = domain.FileStatusStored
+	record.UpdatedAt = time.Now().UTC()
+	scanErr := f.scan.ScanFile(ctx, &record)
+	if scanErr == nil {
+		deduplicateContent(ctx, f.fileRepository, f.metadataRepository, &record)
+	}
+	if scanErr != nil {
+		if err := f.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record); err != nil {
+			return domain.UploadResult{}, err
+		}
+		return domain.UploadResult{}, scanErr
+	}
+
+	taskID, err := f.ingest.IngestFile(context.WithoutCancel(ctx), record)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	return domain.UploadResult{FileID: record.ID, Path: record.StoragePath, Checksum: record.Checksum, Version: record.Version, TaskID: taskID}, nil
 }
 
-func NewFileUploadUseCase(storageAdapter *storage.BlobStorageAdapter) FileUploadUseCase {
-	return &fileUploadImpl{storageAdapter: storageAdapter}
+// markFailed moves record to the failed state, even when ctx has been cancelled.
+func (f *fileUploadImpl) markFailed(ctx context.Context, record domain.FileRecord) {
+	record.Status = domain.FileStatusFailed
+	record.UpdatedAt = time.Now().UTC()
+	_ = f.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record)
 }
 
-func (f *fileUploadImpl) HandleFileUpload(ctx context.Context, file domain.UploadedFile) error {
+// newFileRecord creates the pending metadata record of an upload and allocates its
+// storage key from the file's owner, chat and name.
+func newFileRecord(file domain.UploadedFile) (domain.FileRecord, error) {
+	id := uuid.New().String()
+	key, err := objectKey(file.Owner, file.ChatID, id, file.Name)
+	if err != nil {
+		return domain.FileRecord{}, err
+	}
+
+	now := time.Now().UTC()
+	return domain.FileRecord{
+		ID:           id,
+		Owner:        file.Owner,
+		ChatID:       file.ChatID,
+		OriginalName: file.Name,
+		ContentType:  file.ContentType,
+		Size:         file.Size,
+		StoragePath:  key,
+		Status:       domain.FileStatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
 
-	return f.storageAdapter.UploadFile(ctx, file)
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
 }
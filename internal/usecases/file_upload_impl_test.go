@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+// failingFileRepository fails every SaveFile call.
+type failingFileRepository struct {
+	*fakeFileRepository
+}
+
+func (failingFileRepository) SaveFile(ctx context.Context, file domain.UploadedFile) error {
+	return errors.New("storage unavailable")
+}
+
+func TestFileUpload_RecordsMetadata(t *testing.T) {
+	metadata := newFakeMetadataRepository()
+	upload := NewFileUploadUseCase(newFakeFileRepository(), metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(nil, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+	ctx := context.Background()
+
+	result, err := upload.HandleFileUpload(ctx, domain.UploadedFile{
+		Name:        "hello.txt",
+		ContentType: "text/plain",
+		File:        strings.NewReader("hello"),
+		Size:        5,
+		Owner:       "alice",
+		ChatID:      "chat-1",
+	})
+	if err != nil {
+		t.Fatalf("HandleFileUpload() error = %v", err)
+	}
+
+	record, err := metadata.GetFileRecord(ctx, result.FileID)
+	if err != nil {
+		t.Fatalf("GetFileRecord(%q) error = %v", result.FileID, err)
+	}
+	// SHA-256 of "hello".
+	const checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if record.Status != domain.FileStatusStored || record.Checksum != checksum || record.Owner != "alice" || record.StoragePath != result.Path {
+		t.Errorf("GetFileRecord() = %+v, want stored record of alice with checksum %s", record, checksum)
+	}
+	if want := "alice/chat-1/" + result.FileID + "_hello.txt"; result.Path != want || record.OriginalName != "hello.txt" {
+		t.Errorf("HandleFileUpload() path = %q, name = %q, want %q, %q", result.Path, record.OriginalName, want, "hello.txt")
+	}
+}
+
+func TestFileUpload_MarksFailedUploads(t *testing.T) {
+	metadata := newFakeMetadataRepository()
+	upload := NewFileUploadUseCase(failingFileRepository{newFakeFileRepository()}, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(nil, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+
+	_, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{File: strings.NewReader("x"), Name: "x.txt", Owner: "alice", ChatID: "chat-1"})
+	if err == nil {
+		t.Fatal("HandleFileUpload() error = nil, want storage error")
+	}
+
+	for _, record := range metadata.records {
+		if record.Status != domain.FileStatusFailed {
+			t.Errorf("record status = %q, want %q", record.Status, domain.FileStatusFailed)
+		}
+	}
+	if len(metadata.records) != 1 {
+		t.Errorf("got %d records, want 1", len(metadata.records))
+	}
+}
+
+func TestFileUpload_VerifiesChecksum(t *testing.T) {
+	repo, metadata := newFakeFileRepository(), newFakeMetadataRepository()
+	upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+	// SHA-256 of "hello".
+	const checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	tests := []struct {
+		name     string
+		content  string
+		checksum string
+		err      error
+	}{
+		{name: "matching", content: "hello", checksum: checksum},
+		{name: "matching upper case", content: "hello", checksum: strings.ToUpper(checksum)},
+		{name: "mismatch", content: "hello!", checksum: checksum, err: domain.ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+				Name: "hello.txt", File: strings.NewReader(tt.content), Owner: "alice", ChatID: tt.name, Checksum: tt.checksum,
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("HandleFileUpload() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	files, _ := repo.ListFiles(context.Background(), "alice/mismatch/")
+	if len(files) != 0 {
+		t.Errorf("ListFiles() after mismatch = %+v, want the upload deleted", files)
+	}
+}
+
+func TestFileUpload_DeduplicatesContent(t *testing.T) {
+	repo, metadata := newFakeFileRepository(), newFakeMetadataRepository()
+	upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+	manage := NewFileManagementUseCase(repo, metadata)
+	ctx := context.Background()
+
+	uploadTo := func(owner, chatID string) domain.UploadResult {
+		t.Helper()
+		result, err := upload.HandleFileUpload(ctx, domain.UploadedFile{Name: "report.pdf", File: strings.NewReader("%PDF-1.7"), Owner: owner, ChatID: chatID})
+		if err != nil {
+			t.Fatalf("HandleFileUpload(%s, %s) error = %v", owner, chatID, err)
+		}
+		return result
+	}
+	first, second, other := uploadTo("alice", "chat-1"), uploadTo("alice", "chat-2"), uploadTo("bob", "chat-1")
+
+	record, _ := metadata.GetFileRecord(ctx, second.FileID)
+	if record.BlobPath != first.Path || record.Checksum != first.Checksum {
+		t.Errorf("second upload record = %+v, want content shared with %s", record, first.Path)
+	}
+	if record, _ := metadata.GetFileRecord(ctx, other.FileID); record.BlobPath != "" {
+		t.Errorf("other owner's record BlobPath = %q, want its own copy", record.BlobPath)
+	}
+	if len(repo.data) != 2 {
+		t.Errorf("stored %d blobs, want 2", len(repo.data))
+	}
+
+	secondName := second.FileID + "_report.pdf"
+	files, err := manage.ListFiles(ctx, "alice", "chat-2")
+	if err != nil || len(files) != 1 || files[0].Path != second.Path {
+		t.Fatalf("ListFiles(alice, chat-2) = %+v, %v, want %s", files, err, second.Path)
+	}
+
+	// Deleting the original keeps the content for the second upload.
+	if err := manage.DeleteFile(ctx, "alice", "chat-1", first.FileID+"_report.pdf"); err != nil {
+		t.Fatalf("DeleteFile(original) error = %v", err)
+	}
+	if files, _ := manage.ListFiles(ctx, "alice", "chat-1"); len(files) != 0 {
+		t.Errorf("ListFiles(alice, chat-1) after delete = %+v, want none", files)
+	}
+	if _, err := manage.StatFile(ctx, "alice", "chat-1", first.FileID+"_report.pdf"); !errors.Is(err, domain.ErrFileNotFound) {
+		t.Errorf("StatFile(original) after delete error = %v, want %v", err, domain.ErrFileNotFound)
+	}
+	stored, err := manage.GetFile(ctx, "alice", "chat-2", secondName, nil)
+	if err != nil {
+		t.Fatalf("GetFile(second) after deleting the original error = %v", err)
+	}
+	stored.Content.Close()
+	if stored.Path != second.Path {
+		t.Errorf("GetFile(second).Path = %q, want %q", stored.Path, second.Path)
+	}
+
+	// Purging the last reference removes the content.
+	if err := manage.DeleteFile(ctx, "alice", "chat-2", secondName); err != nil {
+		t.Fatalf("DeleteFile(second) error = %v", err)
+	}
+	if _, ok := repo.data[first.Path]; !ok {
+		t.Errorf("content at %s removed before the deleted files were purged", first.Path)
+	}
+	if _, err := NewFilePurgeUseCase(repo, metadata, 0).PurgeDeletedFiles(ctx); err != nil {
+		t.Fatalf("PurgeDeletedFiles() error = %v", err)
+	}
+	if _, ok := repo.data[first.Path]; ok {
+		t.Errorf("content at %s kept after deleting every file sharing it", first.Path)
+	}
+}
@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+
+	"chat-backend-general/internal/domain"
+)
+
+// FileVersionUseCase exposes the version history of documents, the uploads of the same
+// filename to a chat.
+type FileVersionUseCase interface {
+	// ListVersions returns the stored, deleted and purged versions of the document the
+	// file id belongs to, newest first.
+	ListVersions(ctx context.Context, id string) ([]domain.FileRecord, error)
+	// RestoreVersion makes the content of file id the newest version of its document,
+	// undeleting the document if needed. It fails with domain.ErrVersionUnavailable once
+	// the content has been purged.
+	RestoreVersion(ctx context.Context, id string) (domain.UploadResult, error)
+}
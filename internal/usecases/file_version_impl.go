@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fileVersionImpl struct {
+	fileRepository     domain.FileRepository
+	metadataRepository domain.FileMetadataRepository
+	quota              QuotaUseCase
+}
+
+func NewFileVersionUseCase(fileRepository domain.FileRepository, metadataRepository domain.FileMetadataRepository, quota QuotaUseCase) FileVersionUseCase {
+	return &fileVersionImpl{fileRepository: fileRepository, metadataRepository: metadataRepository, quota: quota}
+}
+
+// ListVersions returns the versions of the document of file id that were stored
+// successfully, leaving out failed and rejected uploads.
+func (v *fileVersionImpl) ListVersions(ctx context.Context, id string) ([]domain.FileRecord, error) {
+	file, err := v.getFileRecord(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	records, err := v.metadataRepository.ListDocumentVersions(ctx, file.Owner, file.ChatID, file.OriginalName)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]domain.FileRecord, 0, len(records))
+	for _, record := range records {
+		switch record.Status {
+		case domain.FileStatusStored, domain.FileStatusDeleted, domain.FileStatusPurged:
+			versions = append(versions, record)
+		}
+	}
+	return versions, nil
+}
+
+// RestoreVersion records a new version of the document of file id sharing its content,
+// so the history up to the restore is kept. The restored version counts towards the
+// owner's quota like an upload.
+func (v *fileVersionImpl) RestoreVersion(ctx context.Context, id string) (domain.UploadResult, error) {
+	source, err := v.getFileRecord(ctx, id)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	if source.Status != domain.FileStatusStored && source.Status != domain.FileStatusDeleted {
+		return domain.UploadResult{}, domain.ErrVersionUnavailable
+	}
+
+	record, err := newFileRecord(domain.UploadedFile{
+		Name: source.OriginalName, ContentType: source.ContentType, Size: source.Size, Owner: source.Owner, ChatID: source.ChatID,
+	})
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	record.Checksum, record.BlobPath = source.Checksum, source.ContentPath()
+	record.ScanVerdict, record.ScanSignature, record.ScannedAt = source.ScanVerdict, source.ScanSignature, source.ScannedAt
+	// Reference the content before checking it still exists, so that a concurrent purge
+	// either sees this reference or is seen by the check.
+	if err := createVersionedRecord(ctx, v.metadataRepository, &record); err != nil {
+		return domain.UploadResult{}, err
+	}
+	if err := v.quota.CheckQuota(ctx, record.Owner, record.ChatID); err != nil {
+		v.markFailed(ctx, record)
+		return domain.UploadResult{}, err
+	}
+	if _, err := v.fileRepository.StatFile(ctx, record.BlobPath); err != nil {
+		v.markFailed(ctx, record)
+		if errors.Is(err, domain.ErrFileNotFound) {
+			return domain.UploadResult{}, domain.ErrVersionUnavailable
+		}
+		return domain.UploadResult{}, err
+	}
+
+	record.Status = domain.FileStatusStored
+	record.UpdatedAt = time.Now().UTC()
+	if err := v.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record); err != nil {
+		return domain.UploadResult{}, err
+	}
+	return domain.UploadResult{FileID: record.ID, Path: record.StoragePath, Checksum: record.Checksum, Version: record.Version}, nil
+}
+
+func (v *fileVersionImpl) getFileRecord(ctx context.Context, id string) (domain.FileRecord, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return domain.FileRecord{}, domain.ErrFileRecordNotFound
+	}
+	return v.metadataRepository.GetFileRecord(ctx, id)
+}
+
+// markFailed moves record to the failed state, even when ctx has been cancelled.
+func (v *fileVersionImpl) markFailed(ctx context.Context, record domain.FileRecord) {
+	record.Status = domain.FileStatusFailed
+	record.UpdatedAt = time.Now().UTC()
+	_ = v.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record)
+}
@@ -0,0 +1,148 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+// versionFixture uploads three versions of report.pdf, with the contents v1, v2 and v3, to alice's chat-1.
+func versionFixture(t *testing.T) (*fakeFileRepository, *fakeMetadataRepository, []domain.UploadResult) {
+	t.Helper()
+	repo, metadata := newFakeFileRepository(), newFakeMetadataRepository()
+	upload := NewFileUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil), NewFileScanUseCase(repo, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+
+	var results []domain.UploadResult
+	for _, content := range []string{"v1", "v2", "v3"} {
+		result, err := upload.HandleFileUpload(context.Background(), domain.UploadedFile{
+			Name: "report.pdf", File: strings.NewReader(content), Owner: "alice", ChatID: "chat-1",
+		})
+		if err != nil {
+			t.Fatalf("HandleFileUpload(%s) error = %v", content, err)
+		}
+		results = append(results, result)
+	}
+	return repo, metadata, results
+}
+
+func readFile(t *testing.T, manage FileManagementUseCase, path string) string {
+	t.Helper()
+	stored, err := manage.GetFile(context.Background(), "alice", "chat-1", path[strings.LastIndex(path, "/")+1:], nil)
+	if err != nil {
+		t.Fatalf("GetFile(%s) error = %v", path, err)
+	}
+	defer stored.Content.Close()
+	content, _ := io.ReadAll(stored.Content)
+	return string(content)
+}
+
+func versionNumbers(records []domain.FileRecord) []int {
+	var numbers []int
+	for _, record := range records {
+		numbers = append(numbers, record.Version)
+	}
+	return numbers
+}
+
+func TestFileVersion_UploadsCreateVersions(t *testing.T) {
+	repo, metadata, results := versionFixture(t)
+	manage := NewFileManagementUseCase(repo, metadata)
+	versions := NewFileVersionUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil))
+	ctx := context.Background()
+
+	for i, result := range results {
+		if result.Version != i+1 {
+			t.Errorf("HandleFileUpload() #%d version = %d, want %d", i, result.Version, i+1)
+		}
+	}
+
+	files, err := manage.ListFiles(ctx, "alice", "chat-1")
+	if err != nil || len(files) != 1 || files[0].Path != results[2].Path {
+		t.Errorf("ListFiles() = %+v, %v, want only the latest version %s", files, err, results[2].Path)
+	}
+	if got := readFile(t, manage, results[0].Path); got != "v1" {
+		t.Errorf("GetFile(version 1) = %q, want %q", got, "v1")
+	}
+
+	list, err := versions.ListVersions(ctx, results[0].FileID)
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if got, want := versionNumbers(list), []int{3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("ListVersions() versions = %v, want %v", got, want)
+	}
+}
+
+func TestFileVersion_RestoreVersion(t *testing.T) {
+	repo, metadata, results := versionFixture(t)
+	manage := NewFileManagementUseCase(repo, metadata)
+	versions := NewFileVersionUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil))
+	ctx := context.Background()
+
+	restored, err := versions.RestoreVersion(ctx, results[0].FileID)
+	if err != nil {
+		t.Fatalf("RestoreVersion() error = %v", err)
+	}
+	if restored.Version != 4 || restored.Checksum != results[0].Checksum {
+		t.Errorf("RestoreVersion() = %+v, want version 4 with checksum %s", restored, results[0].Checksum)
+	}
+	if got := readFile(t, manage, restored.Path); got != "v1" {
+		t.Errorf("GetFile(restored) = %q, want %q", got, "v1")
+	}
+	files, _ := manage.ListFiles(ctx, "alice", "chat-1")
+	if len(files) != 1 || files[0].Path != restored.Path {
+		t.Errorf("ListFiles() after restore = %+v, want only %s", files, restored.Path)
+	}
+
+	if _, err := versions.RestoreVersion(ctx, "not-a-uuid"); !errors.Is(err, domain.ErrFileRecordNotFound) {
+		t.Errorf("RestoreVersion(not-a-uuid) error = %v, want %v", err, domain.ErrFileRecordNotFound)
+	}
+}
+
+func TestFileVersion_RestoreDeletedDocument(t *testing.T) {
+	repo, metadata, results := versionFixture(t)
+	manage := NewFileManagementUseCase(repo, metadata)
+	versions := NewFileVersionUseCase(repo, metadata, NewQuotaUseCase(metadata, domain.QuotaLimits{}, nil))
+	ctx := context.Background()
+
+	if err := manage.DeleteFile(ctx, "alice", "chat-1", results[2].Path[len("alice/chat-1/"):]); err != nil {
+		t.Fatalf("DeleteFile() error = %v", err)
+	}
+	if files, _ := manage.ListFiles(ctx, "alice", "chat-1"); len(files) != 0 {
+		t.Errorf("ListFiles() after delete = %+v, want none", files)
+	}
+	list, _ := versions.ListVersions(ctx, results[0].FileID)
+	for _, record := range list {
+		if record.Status != domain.FileStatusDeleted || record.DeletedAt == nil {
+			t.Errorf("version %d after delete = %s at %v, want deleted", record.Version, record.Status, record.DeletedAt)
+		}
+	}
+
+	restored, err := versions.RestoreVersion(ctx, results[1].FileID)
+	if err != nil {
+		t.Fatalf("RestoreVersion(deleted) error = %v", err)
+	}
+	if got := readFile(t, manage, restored.Path); got != "v2" {
+		t.Errorf("GetFile(restored) = %q, want %q", got, "v2")
+	}
+
+	// Purging removes the deleted versions, but not the content of the restored one.
+	purged, err := NewFilePurgeUseCase(repo, metadata, 0).PurgeDeletedFiles(ctx)
+	if err != nil || purged != 3 {
+		t.Fatalf("PurgeDeletedFiles() = %d, %v, want 3", purged, err)
+	}
+	if got := readFile(t, manage, restored.Path); got != "v2" {
+		t.Errorf("GetFile(restored) after purge = %q, want %q", got, "v2")
+	}
+	if _, ok := repo.data[results[0].Path]; ok {
+		t.Errorf("content of purged version 1 kept")
+	}
+	if _, err := versions.RestoreVersion(ctx, results[0].FileID); !errors.Is(err, domain.ErrVersionUnavailable) {
+		t.Errorf("RestoreVersion(purged) error = %v, want %v", err, domain.ErrVersionUnavailable)
+	}
+}
@@ -0,0 +1,30 @@
+package usecases
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var locks keyedMutex
+	var wg sync.WaitGroup
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		key := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.lock(key)
+			defer unlock()
+			counts[key]++ // Racy unless the lock serializes the same key
+		}()
+	}
+	wg.Wait()
+
+	if counts["a"] != 50 || counts["b"] != 50 {
+		t.Errorf("counts = %v, want 50 for each key", counts)
+	}
+	if n := locks.len(); n != 0 {
+		t.Errorf("len() after every unlock = %d, want 0", n)
+	}
+}
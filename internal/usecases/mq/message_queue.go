@@ -1,10 +1,16 @@
 package mq
 
-import "chat-backend-general/internal/domain"
+import (
+    "chat-backend-general/internal/domain"
+    "time"
+)
 
 // MessageQueueUseCase defines the interface for message queue use cases
 type MessageQueueUseCase interface {
-    Publish(queueName string, payload domain.CeleryMessage) error
+    // Publish returns the schedule ID of a message held back until its ETA, if any
+    Publish(queueName string, payload domain.CeleryMessage) (string, error)
+    // CancelScheduled cancels a message published with an ETA that has not been delivered yet
+    CancelScheduled(queueName, scheduleID string) error
 }
 
 // messageQueueUseCaseImpl is the concrete implementation of MessageQueueUseCase
@@ -17,7 +23,21 @@ func NewMessageQueueUseCase(queue domain.MessageQueue) MessageQueueUseCase {
     return &messageQueueUseCaseImpl{queue: queue}
 }
 
-// Publish sends a Celery-compatible message to the specified queue
-func (m *messageQueueUseCaseImpl) Publish(queueName string, payload domain.CeleryMessage) error {
-    return m.queue.PublishMessage(queueName, payload)
+// Publish sends a Celery-compatible message to the specified queue. A message with a
+// future ETA is scheduled when the broker supports it, and its schedule ID returned.
+func (m *messageQueueUseCaseImpl) Publish(queueName string, payload domain.CeleryMessage) (string, error) {
+    scheduler, ok := m.queue.(domain.MessageScheduler)
+    if ok && payload.ETA != nil && payload.ETA.After(time.Now()) {
+        return scheduler.ScheduleMessage(queueName, payload)
+    }
+    return "", m.queue.PublishMessage(queueName, payload)
+}
+
+// CancelScheduled cancels a scheduled message, when the broker supports it
+func (m *messageQueueUseCaseImpl) CancelScheduled(queueName, scheduleID string) error {
+    scheduler, ok := m.queue.(domain.MessageScheduler)
+    if !ok {
+        return domain.ErrSchedulingUnsupported
+    }
+    return scheduler.CancelScheduledMessage(queueName, scheduleID)
 }
@@ -0,0 +1,12 @@
+package usecases
+
+import (
+	"context"
+)
+
+// OutboxRelayUseCase publishes the messages of the outbox to the message queue.
+type OutboxRelayUseCase interface {
+	// RelayMessages publishes the due messages and returns how many were published. Failed
+	// messages are retried later with backoff; their errors are returned joined.
+	RelayMessages(ctx context.Context) (int, error)
+}
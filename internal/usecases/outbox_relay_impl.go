@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// outboxRetryDelay is the delay before the first retry of a message; it doubles with
+// every further attempt, up to the configured maximum.
+const outboxRetryDelay = time.Second
+
+type outboxRelayImpl struct {
+	outbox     domain.OutboxRepository
+	queue      domain.MessageQueue
+	batchSize  int
+	lease      time.Duration
+	maxBackoff time.Duration
+}
+
+// NewOutboxRelayUseCase creates an OutboxRelayUseCase claiming batchSize messages at a
+// time, each for lease, and retrying failed ones after at most maxBackoff.
+func NewOutboxRelayUseCase(outbox domain.OutboxRepository, queue domain.MessageQueue, batchSize int, lease, maxBackoff time.Duration) OutboxRelayUseCase {
+	return &outboxRelayImpl{outbox: outbox, queue: queue, batchSize: max(batchSize, 1), lease: lease, maxBackoff: maxBackoff}
+}
+
+// RelayMessages claims due messages batch by batch. A published message is deleted from
+// the outbox afterwards, so it is published again if the process stops in between. So is
+// a message that expired before it could be published.
+func (r *outboxRelayImpl) RelayMessages(ctx context.Context) (int, error) {
+	published := 0
+	var errs []error
+	for {
+		messages, err := r.outbox.ClaimOutboxMessages(ctx, time.Now().UTC(), r.lease, r.batchSize)
+		if err != nil {
+			return published, errors.Join(append(errs, err)...)
+		}
+		for _, message := range messages {
+			publishErr := r.queue.PublishMessage(message.Queue, message.Message)
+			if publishErr != nil && !errors.Is(publishErr, domain.ErrMessageExpired) {
+				errs = append(errs, fmt.Errorf("failed to publish outbox message %s: %w", message.ID, publishErr))
+				if err := r.outbox.RetryOutboxMessage(ctx, message.ID, time.Now().UTC().Add(r.backoff(message.Attempts)), publishErr.Error()); err != nil {
+					return published, errors.Join(append(errs, err)...)
+				}
+				continue
+			}
+			if err := r.outbox.DeleteOutboxMessage(ctx, message.ID); err != nil && !errors.Is(err, domain.ErrOutboxMessageNotFound) {
+				return published, errors.Join(append(errs, err)...)
+			}
+			if publishErr != nil {
+				// Retrying cannot help: the message is dropped, as the broker drops expired messages.
+				errs = append(errs, fmt.Errorf("dropped outbox message %s: %w", message.ID, publishErr))
+				continue
+			}
+			published++
+		}
+		if len(messages) < r.batchSize {
+			return published, errors.Join(errs...)
+		}
+	}
+}
+
+// backoff returns the delay before retrying a message after its attempts-th attempt failed.
+func (r *outboxRelayImpl) backoff(attempts int) time.Duration {
+	delay := outboxRetryDelay
+	for i := 1; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.maxBackoff)
+}
@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// queueOutboxMessages adds n messages, due now, to outbox.
+func queueOutboxMessages(outbox *fakeOutboxRepository, n int) {
+	now := time.Now().UTC()
+	for i := range n {
+		id := fmt.Sprintf("task-%d", i)
+		outbox.messages[id] = domain.OutboxMessage{
+			ID: id, Queue: "default", CreatedAt: now, NextAttemptAt: now,
+			Message: domain.NewCeleryMessage("tasks.ingest_document", []interface{}{}, map[string]interface{}{}),
+		}
+	}
+}
+
+func TestOutboxRelay_RelayMessages(t *testing.T) {
+	tests := []struct {
+		name      string
+		messages  int
+		batchSize int
+		queueErr  error
+		published int
+	}{
+		{name: "empty outbox", batchSize: 10},
+		{name: "single batch", messages: 3, batchSize: 10, published: 3},
+		{name: "several batches", messages: 7, batchSize: 2, published: 7},
+		{name: "broker down", messages: 3, batchSize: 2, queueErr: errors.New("broker down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := newFakeOutboxRepository(newFakeMetadataRepository())
+			queueOutboxMessages(outbox, tt.messages)
+			queue := &fakeMessageQueue{err: tt.queueErr}
+
+			published, err := NewOutboxRelayUseCase(outbox, queue, tt.batchSize, time.Minute, time.Minute).RelayMessages(context.Background())
+			if (err != nil) != (tt.queueErr != nil) {
+				t.Fatalf("RelayMessages() error = %v, want error %v", err, tt.queueErr)
+			}
+			if published != tt.published || len(queue.messages) != tt.published {
+				t.Errorf("RelayMessages() = %d with %d messages sent, want %d", published, len(queue.messages), tt.published)
+			}
+			if remaining := len(outbox.messages); remaining != tt.messages-tt.published {
+				t.Errorf("%d messages left in the outbox, want %d", remaining, tt.messages-tt.published)
+			}
+			for _, message := range outbox.messages {
+				if message.LastError == "" || !message.NextAttemptAt.After(time.Now()) {
+					t.Errorf("failed message %+v, want the error recorded and a later attempt", message)
+				}
+			}
+		})
+	}
+}
+
+func TestOutboxRelay_RetriesWithBackoff(t *testing.T) {
+	outbox := newFakeOutboxRepository(newFakeMetadataRepository())
+	queueOutboxMessages(outbox, 1)
+	queue := &fakeMessageQueue{err: errors.New("broker down")}
+	relay := NewOutboxRelayUseCase(outbox, queue, 10, time.Minute, 3*time.Second)
+
+	// Make every failed message due again at once to observe the delays chosen by the relay.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		start := time.Now()
+		if _, err := relay.RelayMessages(context.Background()); err == nil {
+			t.Fatal("RelayMessages() error = nil, want the publish error")
+		}
+		message := outbox.messages["task-0"]
+		if delay := message.NextAttemptAt.Sub(start); delay < want || delay > want+time.Second {
+			t.Errorf("attempt %d retried after %v, want %v", message.Attempts, delay, want)
+		}
+		message.NextAttemptAt = time.Now().UTC()
+		outbox.messages[message.ID] = message
+	}
+
+	queue.err = nil
+	if published, err := relay.RelayMessages(context.Background()); err != nil || published != 1 {
+		t.Errorf("RelayMessages() after recovery = %d, %v, want 1 published", published, err)
+	}
+	if len(outbox.messages) != 0 {
+		t.Errorf("outbox = %v, want empty once published", outbox.messages)
+	}
+}
+
+func TestOutboxRelay_SkipsClaimedMessages(t *testing.T) {
+	outbox := newFakeOutboxRepository(newFakeMetadataRepository())
+	queueOutboxMessages(outbox, 2)
+	// Another relay holds the lease of these messages.
+	if _, err := outbox.ClaimOutboxMessages(context.Background(), time.Now().UTC(), time.Minute, 10); err != nil {
+		t.Fatalf("ClaimOutboxMessages() error = %v", err)
+	}
+
+	queue := &fakeMessageQueue{}
+	published, err := NewOutboxRelayUseCase(outbox, queue, 10, time.Minute, time.Minute).RelayMessages(context.Background())
+	if err != nil || published != 0 || len(queue.messages) != 0 {
+		t.Errorf("RelayMessages() = %d, %v with %d sent, want leased messages skipped", published, err, len(queue.messages))
+	}
+}
+
+func TestOutboxRelay_DropsExpiredMessages(t *testing.T) {
+	outbox := newFakeOutboxRepository(newFakeMetadataRepository())
+	queueOutboxMessages(outbox, 1)
+	queue := &fakeMessageQueue{err: fmt.Errorf("publishing: %w", domain.ErrMessageExpired)}
+
+	published, err := NewOutboxRelayUseCase(outbox, queue, 10, time.Minute, time.Minute).RelayMessages(context.Background())
+	if !errors.Is(err, domain.ErrMessageExpired) || published != 0 {
+		t.Errorf("RelayMessages() = %d, %v, want the expired message reported", published, err)
+	}
+	if len(outbox.messages) != 0 {
+		t.Errorf("outbox = %v, want the expired message dropped", outbox.messages)
+	}
+}
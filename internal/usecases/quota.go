@@ -0,0 +1,15 @@
+package usecases
+
+import (
+	"context"
+
+	"chat-backend-general/internal/domain"
+)
+
+// QuotaUseCase enforces per-user storage quotas, counting files from the metadata store.
+type QuotaUseCase interface {
+	// CheckQuota fails with domain.ErrQuotaBytesExceeded or domain.ErrQuotaFilesExceeded
+	// when the usage of username, or of its chat, is over the limits.
+	CheckQuota(ctx context.Context, username, chatID string) error
+	GetUsage(ctx context.Context, username, chatID string) (domain.QuotaReport, error)
+}
@@ -0,0 +1,86 @@
+// internal/usecases/quota_impl.go
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+)
+
+type quotaImpl struct {
+	metadataRepository domain.FileMetadataRepository
+	defaults           domain.QuotaLimits
+	tenants            map[string]domain.QuotaLimits
+}
+
+// NewQuotaUseCase creates a QuotaUseCase. Users listed in tenants get those limits
+// instead of defaults.
+func NewQuotaUseCase(metadataRepository domain.FileMetadataRepository, defaults domain.QuotaLimits, tenants map[string]domain.QuotaLimits) QuotaUseCase {
+	return &quotaImpl{metadataRepository: metadataRepository, defaults: defaults, tenants: tenants}
+}
+
+// CheckQuota compares the usage of username and of its chat with their limits. Pending
+// uploads count towards the usage, so callers record an upload before checking it and
+// concurrent uploads cannot overshoot the quota together.
+func (q *quotaImpl) CheckQuota(ctx context.Context, username, chatID string) error {
+	limits := q.limits(username)
+	if limits.MaxBytes > 0 || limits.MaxFiles > 0 {
+		usage, err := q.metadataRepository.GetUsage(ctx, username, "")
+		if err != nil {
+			return err
+		}
+		if err := checkLimits(usage, limits.MaxBytes, limits.MaxFiles); err != nil {
+			return err
+		}
+	}
+
+	if limits.ChatMaxBytes > 0 || limits.ChatMaxFiles > 0 {
+		usage, err := q.metadataRepository.GetUsage(ctx, username, chatID)
+		if err != nil {
+			return err
+		}
+		if err := checkLimits(usage, limits.ChatMaxBytes, limits.ChatMaxFiles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUsage reports the usage and limits of username, and of its chat when chatID is set.
+func (q *quotaImpl) GetUsage(ctx context.Context, username, chatID string) (domain.QuotaReport, error) {
+	if !validSegment(username) || (chatID != "" && !validSegment(chatID)) {
+		return domain.QuotaReport{}, domain.ErrInvalidPath
+	}
+
+	usage, err := q.metadataRepository.GetUsage(ctx, username, "")
+	if err != nil {
+		return domain.QuotaReport{}, err
+	}
+	report := domain.QuotaReport{Username: username, Usage: usage, Limits: q.limits(username)}
+
+	if chatID != "" {
+		chatUsage, err := q.metadataRepository.GetUsage(ctx, username, chatID)
+		if err != nil {
+			return domain.QuotaReport{}, err
+		}
+		report.ChatID, report.ChatUsage = chatID, &chatUsage
+	}
+	return report, nil
+}
+
+func (q *quotaImpl) limits(username string) domain.QuotaLimits {
+	if limits, ok := q.tenants[username]; ok {
+		return limits
+	}
+	return q.defaults
+}
+
+// checkLimits returns the quota error for usage over maxBytes or maxFiles; zero limits are ignored.
+func checkLimits(usage domain.Usage, maxBytes, maxFiles int64) error {
+	if maxFiles > 0 && usage.Files > maxFiles {
+		return domain.ErrQuotaFilesExceeded
+	}
+	if maxBytes > 0 && usage.Bytes > maxBytes {
+		return domain.ErrQuotaBytesExceeded
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestQuota_EnforcedOnUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   domain.QuotaLimits
+		tenants  map[string]domain.QuotaLimits
+		uploads  []domain.UploadedFile
+		expected error // Error of the last upload
+	}{
+		{
+			name:     "within limits",
+			limits:   domain.QuotaLimits{MaxBytes: 10, MaxFiles: 2},
+			uploads:  []domain.UploadedFile{{ChatID: "c1", Size: 5}, {ChatID: "c2", Size: 5}},
+			expected: nil,
+		},
+		{
+			name:     "bytes exceeded",
+			limits:   domain.QuotaLimits{MaxBytes: 10},
+			uploads:  []domain.UploadedFile{{ChatID: "c1", Size: 6}, {ChatID: "c2", Size: 5}},
+			expected: domain.ErrQuotaBytesExceeded,
+		},
+		{
+			name:     "files exceeded",
+			limits:   domain.QuotaLimits{MaxFiles: 1},
+			uploads:  []domain.UploadedFile{{ChatID: "c1", Size: 1}, {ChatID: "c2", Size: 1}},
+			expected: domain.ErrQuotaFilesExceeded,
+		},
+		{
+			name:     "chat files exceeded",
+			limits:   domain.QuotaLimits{ChatMaxFiles: 1},
+			uploads:  []domain.UploadedFile{{ChatID: "c1", Size: 1}, {ChatID: "c1", Size: 1}},
+			expected: domain.ErrQuotaFilesExceeded,
+		},
+		{
+			name:     "chat limits are per chat",
+			limits:   domain.QuotaLimits{ChatMaxFiles: 1},
+			uploads:  []domain.UploadedFile{{ChatID: "c1", Size: 1}, {ChatID: "c2", Size: 1}},
+			expected: nil,
+		},
+		{
+			name:     "tenant override",
+			limits:   domain.QuotaLimits{MaxFiles: 1},
+			tenants:  map[string]domain.QuotaLimits{"alice": {MaxFiles: 5}},
+			uploads:  []domain.UploadedFile{{ChatID: "c1", Size: 1}, {ChatID: "c2", Size: 1}},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := newFakeMetadataRepository()
+			upload := NewFileUploadUseCase(newFakeFileRepository(), metadata, NewQuotaUseCase(metadata, tt.limits, tt.tenants), NewFileScanUseCase(nil, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+
+			var err error
+			for i, file := range tt.uploads {
+				file.Owner, file.Name = "alice", "a.txt"
+				file.File = strings.NewReader(strings.Repeat("x", int(file.Size)))
+				_, err = upload.HandleFileUpload(context.Background(), file)
+				if i < len(tt.uploads)-1 && err != nil {
+					t.Fatalf("HandleFileUpload() #%d error = %v", i, err)
+				}
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("HandleFileUpload() error = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuota_DeleteReleasesUsage(t *testing.T) {
+	repo := newFakeFileRepository()
+	metadata := newFakeMetadataRepository()
+	quota := NewQuotaUseCase(metadata, domain.QuotaLimits{MaxFiles: 1}, nil)
+	upload := NewFileUploadUseCase(repo, metadata, quota, NewFileScanUseCase(repo, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""))
+	manage := NewFileManagementUseCase(repo, metadata)
+	ctx := context.Background()
+
+	file := domain.UploadedFile{Owner: "alice", ChatID: "c1", Name: "a.txt", Size: 3}
+	file.File = strings.NewReader("abc")
+	result, err := upload.HandleFileUpload(ctx, file)
+	if err != nil {
+		t.Fatalf("HandleFileUpload() error = %v", err)
+	}
+
+	report, err := quota.GetUsage(ctx, "alice", "c1")
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if report.Usage != (domain.Usage{Files: 1, Bytes: 3}) || report.ChatUsage == nil || *report.ChatUsage != report.Usage || report.Limits.MaxFiles != 1 {
+		t.Errorf("GetUsage() = %+v, want 1 file of 3 bytes in chat c1 with a limit of 1 file", report)
+	}
+
+	if err := manage.DeleteFile(ctx, "alice", "c1", result.FileID+"_a.txt"); err != nil {
+		t.Fatalf("DeleteFile() error = %v", err)
+	}
+	report, err = quota.GetUsage(ctx, "alice", "")
+	if err != nil || report.Usage != (domain.Usage{}) || report.ChatUsage != nil {
+		t.Errorf("GetUsage() after delete = %+v, %v, want no usage", report, err)
+	}
+
+	file.File = strings.NewReader("abc")
+	if _, err := upload.HandleFileUpload(ctx, file); err != nil {
+		t.Errorf("HandleFileUpload() after delete error = %v, want nil", err)
+	}
+}
+
+func TestQuota_GetUsageRejectsInvalidUsername(t *testing.T) {
+	quota := NewQuotaUseCase(newFakeMetadataRepository(), domain.QuotaLimits{}, nil)
+	if _, err := quota.GetUsage(context.Background(), "..", ""); !errors.Is(err, domain.ErrInvalidPath) {
+		t.Errorf("GetUsage(..) error = %v, want %v", err, domain.ErrInvalidPath)
+	}
+}
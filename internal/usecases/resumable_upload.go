@@ -0,0 +1,21 @@
+package usecases
+
+import (
+	"context"
+	"io"
+
+	"chat-backend-general/internal/domain"
+)
+
+// ResumableUploadUseCase uploads a file over several requests, so an interrupted
+// transfer can continue where it stopped instead of starting over.
+type ResumableUploadUseCase interface {
+	CreateUpload(ctx context.Context, username, chatID string, file domain.UploadedFile) (domain.UploadSession, error)
+	GetUpload(ctx context.Context, id string) (domain.UploadSession, error)
+	// WriteChunk appends body at offset; with a checksum, it is discarded unless it matches.
+	WriteChunk(ctx context.Context, id string, offset int64, body io.Reader, checksum *domain.UploadChecksum) (domain.UploadSession, error)
+	AbortUpload(ctx context.Context, id string) error
+	// FailExpiredUploads marks failed the records of uploads that can no longer complete,
+	// releasing their share of the quota, and returns how many it marked.
+	FailExpiredUploads(ctx context.Context) (int, error)
+}
@@ -0,0 +1,376 @@
+// internal/usecases/resumable_upload_impl.go
+package usecases
+
+import (
+	"bytes"
+	"chat-backend-general/internal/domain"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type resumableUploadImpl struct {
+	fileRepository     domain.FileRepository
+	uploader           domain.ChunkedUploader
+	sessions           domain.UploadSessionStore
+	metadataRepository domain.FileMetadataRepository
+	quota              QuotaUseCase
+	scan               FileScanUseCase
+	ingest             FileIngestUseCase
+	validators         []domain.FileValidator // Run against the assembled file
+	chunkSize          int
+	sessionTTL         time.Duration
+	locks              keyedMutex // By upload ID, serializes writes to one upload
+}
+
+// NewResumableUploadUseCase creates a ResumableUploadUseCase. Uploads are rejected with
+// domain.ErrNotSupported when fileRepository cannot stage chunks. validators check the
+// assembled file, for validators that need more than the first chunk.
+func NewResumableUploadUseCase(fileRepository domain.FileRepository, sessions domain.UploadSessionStore, metadataRepository domain.FileMetadataRepository, quota QuotaUseCase, scan FileScanUseCase, ingest FileIngestUseCase, validators []domain.FileValidator, chunkSize int, sessionTTL time.Duration) ResumableUploadUseCase {
+	uploader, _ := fileRepository.(domain.ChunkedUploader)
+	return &resumableUploadImpl{
+		fileRepository:     fileRepository,
+		uploader:           uploader,
+		sessions:           sessions,
+		metadataRepository: metadataRepository,
+		quota:              quota,
+		scan:               scan,
+		ingest:             ingest,
+		validators:         validators,
+		chunkSize:          chunkSize,
+		sessionTTL:         sessionTTL,
+	}
+}
+
+// CreateUpload opens an upload session for file.Size bytes of file.Name in username's chat.
+func (r *resumableUploadImpl) CreateUpload(ctx context.Context, username, chatID string, file domain.UploadedFile) (domain.UploadSession, error) {
+	if r.uploader == nil {
+		return domain.UploadSession{}, domain.ErrNotSupported
+	}
+
+	file.Owner, file.ChatID = username, chatID
+	record, err := newFileRecord(file)
+	if err != nil {
+		return domain.UploadSession{}, err
+	}
+	if err := createVersionedRecord(ctx, r.metadataRepository, &record); err != nil {
+		return domain.UploadSession{}, err
+	}
+	// The announced length counts towards the quota while the upload is pending.
+	if err := r.quota.CheckQuota(ctx, username, chatID); err != nil {
+		record.Status = domain.FileStatusFailed
+		record.UpdatedAt = time.Now().UTC()
+		_ = r.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record)
+		return domain.UploadSession{}, err
+	}
+
+	// The session expires sessionTTL after its record was created, so that any record
+	// still pending by then belongs to an upload that can no longer complete.
+	session := domain.UploadSession{
+		ID:          uuid.New().String(),
+		FileID:      record.ID,
+		Owner:       username,
+		ChatID:      chatID,
+		Path:        record.StoragePath,
+		Name:        file.Name,
+		ContentType: file.ContentType,
+		Length:      file.Size,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.CreatedAt.Add(r.sessionTTL),
+	}
+	if err := r.sessions.CreateSession(ctx, session); err != nil {
+		return domain.UploadSession{}, err
+	}
+
+	// An empty file is complete as soon as it is announced.
+	if session.Completed() {
+		session.TaskID, err = r.complete(ctx, session)
+		return session, err
+	}
+	return session, nil
+}
+
+// GetUpload returns the current state of an upload.
+func (r *resumableUploadImpl) GetUpload(ctx context.Context, id string) (domain.UploadSession, error) {
+	return r.sessions.GetSession(ctx, id)
+}
+
+// WriteChunk appends body to an upload, which must currently be at offset. The body is
+// staged in chunks of chunkSize and the session is updated after every chunk, so if the
+// connection drops only the chunk in flight has to be sent again. The file is assembled
+// once all bytes have arrived. The last chunk is only recorded once the body turned out not
+// to run past the upload length, so an oversized body cannot leave the upload complete.
+// With a checksum, the session is only updated once the whole body has been received and
+// matched it, so a corrupted body is discarded as a whole and the client resends it from
+// offset.
+func (r *resumableUploadImpl) WriteChunk(ctx context.Context, id string, offset int64, body io.Reader, checksum *domain.UploadChecksum) (domain.UploadSession, error) {
+	unlock := r.locks.lock(id)
+	defer unlock()
+
+	session, err := r.sessions.GetSession(ctx, id)
+	if err != nil {
+		return domain.UploadSession{}, err
+	}
+	if session.Offset != offset {
+		return session, domain.ErrUploadOffsetMismatch
+	}
+	saved := session
+	var sum hash.Hash
+	if checksum != nil {
+		if sum = checksum.NewHash(); sum == nil {
+			return session, domain.ErrChecksumAlgorithmUnsupported
+		}
+		body = io.TeeReader(body, sum)
+	}
+
+	buf := make([]byte, r.chunkSize)
+	for !session.Completed() {
+		limit := min(int64(r.chunkSize), session.Length-session.Offset)
+		n, readErr := io.ReadFull(body, buf[:limit])
+		if n > 0 {
+			hashState, err := appendHash(session.HashState, buf[:n])
+			if err != nil {
+				return session, err
+			}
+			if err := r.uploader.StageChunk(ctx, session, session.Chunks, buf[:n]); err != nil {
+				return session, err
+			}
+			session.HashState = hashState
+			session.Offset += int64(n)
+			session.Chunks++
+			if sum == nil && !session.Completed() {
+				if err := r.sessions.UpdateSession(ctx, session); err != nil {
+					return session, err
+				}
+				saved = session
+			}
+		}
+		// EOF ends this request; any other read error means the client went away.
+		// Either way the client resumes from the offset recorded above.
+		if readErr != nil {
+			break
+		}
+	}
+
+	if session.Completed() {
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			return saved, domain.ErrUploadTooLarge
+		}
+	}
+	// Chunks staged for a discarded body are overwritten when it is sent again.
+	if sum != nil && !bytes.Equal(sum.Sum(nil), checksum.Sum) {
+		return saved, domain.ErrChecksumMismatch
+	}
+	if sum != nil || session.Completed() {
+		if err := r.sessions.UpdateSession(ctx, session); err != nil {
+			return saved, err
+		}
+	}
+	if !session.Completed() {
+		return session, nil
+	}
+	session.TaskID, err = r.complete(ctx, session)
+	return session, err
+}
+
+// AbortUpload discards an unfinished upload. Its record is failed first, so the quota
+// is released even when the staged chunks cannot be removed.
+func (r *resumableUploadImpl) AbortUpload(ctx context.Context, id string) error {
+	unlock := r.locks.lock(id)
+	defer unlock()
+
+	session, err := r.sessions.GetSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.updateRecord(ctx, session, domain.FileStatusFailed); err != nil {
+		return err
+	}
+	if err := r.uploader.AbortChunks(ctx, session); err != nil {
+		return err
+	}
+	return r.sessions.DeleteSession(ctx, id)
+}
+
+// FailExpiredUploads marks failed every record still pending a session TTL after it was
+// created: its session has expired, or was lost when the service restarted, and the
+// announced length would otherwise count towards the quota forever. This also covers
+// single-request uploads interrupted by a restart, and signed uploads never finalized,
+// whose content is removed as far as it has been stored.
+func (r *resumableUploadImpl) FailExpiredUploads(ctx context.Context) (int, error) {
+	createdBy := time.Now().UTC().Add(-r.sessionTTL)
+	failed := 0
+	for {
+		records, err := r.metadataRepository.ListStalePendingFileRecords(ctx, createdBy, purgeBatchSize)
+		if err != nil {
+			return failed, err
+		}
+		for _, record := range records {
+			record.Status = domain.FileStatusFailed
+			record.UpdatedAt = time.Now().UTC()
+			if err := r.metadataRepository.UpdateFileRecord(ctx, record); err != nil {
+				return failed, err
+			}
+			_ = r.fileRepository.DeleteFile(ctx, record.StoragePath)
+			failed++
+		}
+		if len(records) < purgeBatchSize {
+			return failed, nil
+		}
+	}
+}
+
+// complete assembles the staged chunks, validates, scans and deduplicates the result,
+// closes the session and returns the ID of the ingestion task queued for the file. A
+// failed commit leaves the session in place so that a retried empty PATCH at the final
+// offset commits again; a failed validation or scan ends the upload.
+func (r *resumableUploadImpl) complete(ctx context.Context, session domain.UploadSession) (string, error) {
+	if err := r.uploader.CommitChunks(ctx, session); err != nil {
+		return "", err
+	}
+	record, err := r.sessionRecord(ctx, session, domain.FileStatusStored)
+	if err != nil {
+		return "", err
+	}
+
+	checkErr := r.validate(ctx, session)
+	if checkErr != nil {
+		_ = r.fileRepository.DeleteFile(context.WithoutCancel(ctx), session.Path)
+		record.Status = domain.FileStatusFailed
+		var failures domain.ValidationErrors
+		if errors.As(checkErr, &failures) {
+			record.Status = domain.FileStatusRejected
+		}
+	} else if checkErr = r.scan.ScanFile(ctx, &record); checkErr == nil {
+		deduplicateContent(ctx, r.fileRepository, r.metadataRepository, &record)
+	}
+
+	// The record and its ingestion task are saved together, so the task is queued exactly
+	// when the upload is stored.
+	var taskID string
+	if checkErr != nil {
+		err = r.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record)
+	} else {
+		taskID, err = r.ingest.IngestFile(context.WithoutCancel(ctx), record)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := r.sessions.DeleteSession(ctx, session.ID); err != nil && !errors.Is(err, domain.ErrUploadNotFound) {
+		return "", err
+	}
+	if checkErr != nil {
+		return "", checkErr
+	}
+	return taskID, nil
+}
+
+// validate runs the validators against the assembled file. Validators such as the archive
+// validator need random access, so the file is spooled to a temporary file when the
+// storage backend only streams it.
+func (r *resumableUploadImpl) validate(ctx context.Context, session domain.UploadSession) error {
+	if len(r.validators) == 0 {
+		return nil
+	}
+
+	stored, err := r.fileRepository.GetFile(ctx, session.Path, nil)
+	if err != nil {
+		return err
+	}
+	defer stored.Content.Close()
+
+	content, cleanup, err := randomAccess(stored.Content)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return domain.ValidateFile(r.validators, domain.UploadedFile{
+		Name:        session.Name,
+		ContentType: session.ContentType,
+		File:        content,
+		Size:        session.Offset,
+		Path:        session.Path,
+		Owner:       session.Owner,
+		ChatID:      session.ChatID,
+	})
+}
+
+// randomAccess returns content as a seekable reader with random access, spooling it to
+// a temporary file unless it already is one. cleanup removes the temporary file.
+func randomAccess(content io.Reader) (io.ReadSeeker, func(), error) {
+	if file, ok := content.(interface {
+		io.ReadSeeker
+		io.ReaderAt
+	}); ok {
+		return file, func() {}, nil
+	}
+
+	spool, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(spool, content); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return spool, cleanup, nil
+}
+
+// updateRecord moves the upload's file record to status, with the checksum of the bytes received.
+func (r *resumableUploadImpl) updateRecord(ctx context.Context, session domain.UploadSession, status domain.FileStatus) error {
+	record, err := r.sessionRecord(ctx, session, status)
+	if err != nil {
+		return err
+	}
+	return r.metadataRepository.UpdateFileRecord(ctx, record)
+}
+
+// sessionRecord returns the upload's file record in status, with the checksum of the bytes received.
+func (r *resumableUploadImpl) sessionRecord(ctx context.Context, session domain.UploadSession, status domain.FileStatus) (domain.FileRecord, error) {
+	record, err := r.metadataRepository.GetFileRecord(ctx, session.FileID)
+	if err != nil {
+		return domain.FileRecord{}, err
+	}
+
+	hash := sha256.New()
+	if len(session.HashState) > 0 {
+		if err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(session.HashState); err != nil {
+			return domain.FileRecord{}, err
+		}
+	}
+	record.Size = session.Offset
+	record.Checksum = hex.EncodeToString(hash.Sum(nil))
+	record.Status = status
+	record.UpdatedAt = time.Now().UTC()
+	return record, nil
+}
+
+// appendHash feeds chunk into a marshalled SHA-256 state, so the checksum of a resumable
+// upload can be computed across requests without keeping the content around.
+func appendHash(state []byte, chunk []byte) ([]byte, error) {
+	hash := sha256.New()
+	if len(state) > 0 {
+		if err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			return nil, err
+		}
+	}
+	hash.Write(chunk)
+	return hash.(encoding.BinaryMarshaler).MarshalBinary()
+}
@@ -0,0 +1,22 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// SignedUploadUseCase uploads files straight to storage through signed URLs. An upload
+// is recorded as pending when its URL is signed, and only counts as stored once its
+// content has been checked like any other upload.
+type SignedUploadUseCase interface {
+	// SignUpload records a pending upload of file.Size bytes of file.Name in username's
+	// chat and returns the URL to send the content to.
+	SignUpload(ctx context.Context, username, chatID string, file domain.UploadedFile, expiry time.Duration) (domain.SignedURL, error)
+	// ReceiveUpload stores content sent to a signed URL served by this service and finalizes it.
+	ReceiveUpload(ctx context.Context, path string, file domain.UploadedFile) (domain.UploadResult, error)
+	// FinalizeUpload validates, scans and queues for ingestion the content uploaded for
+	// the pending record id.
+	FinalizeUpload(ctx context.Context, id string) (domain.UploadResult, error)
+}
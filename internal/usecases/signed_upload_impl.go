@@ -0,0 +1,227 @@
+// internal/usecases/signed_upload_impl.go
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type signedUploadImpl struct {
+	fileRepository     domain.FileRepository
+	metadataRepository domain.FileMetadataRepository
+	quota              QuotaUseCase
+	scan               FileScanUseCase
+	ingest             FileIngestUseCase
+	validators         []domain.FileValidator // Run against the uploaded file
+	sessionTTL         time.Duration          // How long an upload may stay pending
+	locks              keyedMutex             // By file ID, serializes finalizing one upload
+}
+
+// NewSignedUploadUseCase creates a SignedUploadUseCase. Uploads are rejected with
+// domain.ErrNotSupported when fileRepository cannot sign URLs. Their URLs expire within
+// sessionTTL, after which pending uploads are failed by FailExpiredUploads.
+func NewSignedUploadUseCase(fileRepository domain.FileRepository, metadataRepository domain.FileMetadataRepository, quota QuotaUseCase, scan FileScanUseCase, ingest FileIngestUseCase, validators []domain.FileValidator, sessionTTL time.Duration) SignedUploadUseCase {
+	return &signedUploadImpl{
+		fileRepository:     fileRepository,
+		metadataRepository: metadataRepository,
+		quota:              quota,
+		scan:               scan,
+		ingest:             ingest,
+		validators:         validators,
+		sessionTTL:         sessionTTL,
+	}
+}
+
+// SignUpload creates the pending record of the upload as the next version of the
+// document, checks the owner's quota and signs an upload URL for exactly file.Size bytes
+// at the record's storage key. The record is failed when the quota is exceeded. The URL
+// expires no later than the record, so nothing can be uploaded once the record has been
+// failed and its content deleted.
+func (s *signedUploadImpl) SignUpload(ctx context.Context, username, chatID string, file domain.UploadedFile, expiry time.Duration) (domain.SignedURL, error) {
+	signer, ok := s.fileRepository.(domain.URLSigner)
+	if !ok {
+		return domain.SignedURL{}, domain.ErrNotSupported
+	}
+
+	file.Owner, file.ChatID = username, chatID
+	record, err := newFileRecord(file)
+	if err != nil {
+		return domain.SignedURL{}, err
+	}
+	if err := createVersionedRecord(ctx, s.metadataRepository, &record); err != nil {
+		return domain.SignedURL{}, err
+	}
+	// The announced size counts towards the quota while the upload is pending.
+	if err := s.quota.CheckQuota(ctx, username, chatID); err != nil {
+		s.markFailed(ctx, record)
+		return domain.SignedURL{}, err
+	}
+
+	if deadline := time.Until(record.CreatedAt.Add(s.sessionTTL)); expiry > deadline {
+		expiry = deadline
+	}
+	signed, err := signer.SignURL(ctx, record.StoragePath, domain.SignedURLUpload, expiry, record.Size)
+	if err != nil {
+		s.markFailed(ctx, record)
+		return domain.SignedURL{}, err
+	}
+	signed.Path = record.StoragePath
+	signed.FileID = record.ID
+	return signed, nil
+}
+
+// ReceiveUpload stores the content of the pending upload signed for path and finalizes
+// it. Content beyond the announced size is not stored.
+func (s *signedUploadImpl) ReceiveUpload(ctx context.Context, path string, file domain.UploadedFile) (domain.UploadResult, error) {
+	record, err := s.metadataRepository.GetFileRecordByPath(ctx, path)
+	if errors.Is(err, domain.ErrFileRecordNotFound) {
+		return domain.UploadResult{}, domain.ErrUploadNotFound
+	}
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+
+	unlock := s.locks.lock(record.ID)
+	defer unlock()
+
+	record, err = s.pendingRecord(ctx, record.ID)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	file.Path = record.StoragePath
+	file.Owner, file.ChatID = record.Owner, record.ChatID
+	// One byte more than announced is enough to tell an oversized upload apart.
+	file.File = io.LimitReader(file.File, record.Size+1)
+	if err := s.fileRepository.SaveFile(ctx, file); err != nil {
+		return domain.UploadResult{}, err
+	}
+	return s.finalize(ctx, record)
+}
+
+// FinalizeUpload finalizes the upload of the pending record id once its content has been
+// sent to the signed URL. domain.ErrFileNotFound is returned, and the upload left pending,
+// while the content has not arrived.
+func (s *signedUploadImpl) FinalizeUpload(ctx context.Context, id string) (domain.UploadResult, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return domain.UploadResult{}, domain.ErrUploadNotFound
+	}
+
+	unlock := s.locks.lock(id)
+	defer unlock()
+
+	record, err := s.pendingRecord(ctx, id)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	return s.finalize(ctx, record)
+}
+
+// finalize checks the size, checksum and quota of the uploaded content, validates, scans
+// and deduplicates it, and saves the record with its ingestion task. Content that fails
+// a check is deleted and its record failed, or rejected when a validator refused it.
+func (s *signedUploadImpl) finalize(ctx context.Context, record domain.FileRecord) (domain.UploadResult, error) {
+	info, err := s.fileRepository.StatFile(ctx, record.StoragePath)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+
+	var checkErr error
+	if info.Size != record.Size {
+		checkErr = domain.ErrUploadSizeMismatch
+	} else if checkErr = s.quota.CheckQuota(ctx, record.Owner, record.ChatID); checkErr == nil {
+		record.Checksum, checkErr = s.validate(ctx, record)
+	}
+
+	if checkErr != nil {
+		_ = s.fileRepository.DeleteFile(context.WithoutCancel(ctx), record.StoragePath)
+		record.Status = domain.FileStatusFailed
+		var failures domain.ValidationErrors
+		if errors.As(checkErr, &failures) {
+			record.Status = domain.FileStatusRejected
+		}
+		record.UpdatedAt = time.Now().UTC()
+	} else {
+		record.Status = domain.FileStatusStored
+		record.UpdatedAt = time.Now().UTC()
+		if checkErr = s.scan.ScanFile(ctx, &record); checkErr == nil {
+			deduplicateContent(ctx, s.fileRepository, s.metadataRepository, &record)
+		}
+	}
+
+	if checkErr != nil {
+		if err := s.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record); err != nil {
+			return domain.UploadResult{}, err
+		}
+		return domain.UploadResult{}, checkErr
+	}
+
+	taskID, err := s.ingest.IngestFile(context.WithoutCancel(ctx), record)
+	if err != nil {
+		return domain.UploadResult{}, err
+	}
+	return domain.UploadResult{FileID: record.ID, Path: record.StoragePath, Checksum: record.Checksum, Version: record.Version, TaskID: taskID}, nil
+}
+
+// validate returns the checksum of the uploaded content after running the validators
+// against it, spooled to a temporary file when the storage backend only streams it.
+func (s *signedUploadImpl) validate(ctx context.Context, record domain.FileRecord) (string, error) {
+	stored, err := s.fileRepository.GetFile(ctx, record.StoragePath, nil)
+	if err != nil {
+		return "", err
+	}
+	defer stored.Content.Close()
+
+	hash := sha256.New()
+	if len(s.validators) == 0 {
+		if _, err := io.Copy(hash, stored.Content); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	content, cleanup, err := randomAccess(io.TeeReader(stored.Content, hash))
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	err = domain.ValidateFile(s.validators, domain.UploadedFile{
+		Name:        record.OriginalName,
+		ContentType: record.ContentType,
+		File:        content,
+		Size:        record.Size,
+		Path:        record.StoragePath,
+		Owner:       record.Owner,
+		ChatID:      record.ChatID,
+	})
+	return hex.EncodeToString(hash.Sum(nil)), err
+}
+
+// pendingRecord returns the record of an upload that has not been finalized yet.
+func (s *signedUploadImpl) pendingRecord(ctx context.Context, id string) (domain.FileRecord, error) {
+	record, err := s.metadataRepository.GetFileRecord(ctx, id)
+	if errors.Is(err, domain.ErrFileRecordNotFound) {
+		return domain.FileRecord{}, domain.ErrUploadNotFound
+	}
+	if err != nil {
+		return domain.FileRecord{}, err
+	}
+	if record.Status != domain.FileStatusPending {
+		return domain.FileRecord{}, domain.ErrUploadNotFound
+	}
+	return record, nil
+}
+
+// markFailed moves record to the failed state, even when ctx has been cancelled.
+func (s *signedUploadImpl) markFailed(ctx context.Context, record domain.FileRecord) {
+	record.Status = domain.FileStatusFailed
+	record.UpdatedAt = time.Now().UTC()
+	_ = s.metadataRepository.UpdateFileRecord(context.WithoutCancel(ctx), record)
+}
@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-backend-general/internal/domain"
+)
+
+// signingFileRepository signs URLs that point straight at the fake storage.
+type signingFileRepository struct {
+	*fakeFileRepository
+}
+
+func (signingFileRepository) SignURL(ctx context.Context, path string, op domain.SignedURLOperation, expiry time.Duration, size int64) (domain.SignedURL, error) {
+	return domain.SignedURL{URL: "https://storage.test/" + path, Method: "PUT", ExpiresAt: time.Now().Add(expiry)}, nil
+}
+
+func newTestSignedUpload(repo domain.FileRepository, metadata domain.FileMetadataRepository, limits domain.QuotaLimits) SignedUploadUseCase {
+	return NewSignedUploadUseCase(repo, metadata, NewQuotaUseCase(metadata, limits, nil), NewFileScanUseCase(nil, nil, ""), NewFileIngestUseCase(metadata, nil, "", ""), nil, time.Hour)
+}
+
+func TestSignedUpload_SignUpload(t *testing.T) {
+	ctx := context.Background()
+	file := domain.UploadedFile{Name: "report.pdf", ContentType: "application/pdf", Size: 10}
+
+	t.Run("unsupported storage", func(t *testing.T) {
+		upload := newTestSignedUpload(newFakeFileRepository(), newFakeMetadataRepository(), domain.QuotaLimits{})
+		if _, err := upload.SignUpload(ctx, "alice", "chat-1", file, time.Minute); !errors.Is(err, domain.ErrNotSupported) {
+			t.Errorf("SignUpload() error = %v, want %v", err, domain.ErrNotSupported)
+		}
+	})
+
+	t.Run("pending record", func(t *testing.T) {
+		metadata := newFakeMetadataRepository()
+		upload := newTestSignedUpload(signingFileRepository{newFakeFileRepository()}, metadata, domain.QuotaLimits{})
+		signed, err := upload.SignUpload(ctx, "alice", "chat-1", file, time.Minute)
+		if err != nil {
+			t.Fatalf("SignUpload() error = %v", err)
+		}
+		record, err := metadata.GetFileRecord(ctx, signed.FileID)
+		if err != nil || record.Status != domain.FileStatusPending || record.Size != 10 || record.StoragePath != signed.Path {
+			t.Errorf("GetFileRecord() = %+v, %v, want pending record of 10 bytes at %q", record, err, signed.Path)
+		}
+	})
+
+	t.Run("expiry capped at the session TTL", func(t *testing.T) {
+		metadata := newFakeMetadataRepository()
+		upload := newTestSignedUpload(signingFileRepository{newFakeFileRepository()}, metadata, domain.QuotaLimits{})
+		signed, err := upload.SignUpload(ctx, "alice", "chat-1", file, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("SignUpload() error = %v", err)
+		}
+		record, _ := metadata.GetFileRecord(ctx, signed.FileID)
+		// Signers read the clock again, and signed URLs only have second precision.
+		if deadline := record.CreatedAt.Add(time.Hour); signed.ExpiresAt.After(deadline.Add(time.Second)) {
+			t.Errorf("SignUpload() expires at %v, want no later than the record expires at %v", signed.ExpiresAt, deadline)
+		}
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		metadata := newFakeMetadataRepository()
+		upload := newTestSignedUpload(signingFileRepository{newFakeFileRepository()}, metadata, domain.QuotaLimits{MaxBytes: 5})
+		if _, err := upload.SignUpload(ctx, "alice", "chat-1", file, time.Minute); !errors.Is(err, domain.ErrQuotaBytesExceeded) {
+			t.Fatalf("SignUpload() error = %v, want %v", err, domain.ErrQuotaBytesExceeded)
+		}
+		usage, err := metadata.GetUsage(ctx, "alice", "")
+		if err != nil || usage.Bytes != 0 {
+			t.Errorf("GetUsage() = %+v, %v, want the failed upload not to count", usage, err)
+		}
+	})
+}
+
+func TestSignedUpload_ReceiveUpload(t *testing.T) {
+	ctx := context.Background()
+	repo := signingFileRepository{newFakeFileRepository()}
+	metadata := newFakeMetadataRepository()
+	upload := newTestSignedUpload(repo, metadata, domain.QuotaLimits{})
+
+	if _, err := upload.ReceiveUpload(ctx, "alice/chat-1/unknown.txt", domain.UploadedFile{File: strings.NewReader("hello")}); !errors.Is(err, domain.ErrUploadNotFound) {
+		t.Errorf("ReceiveUpload() of unsigned path error = %v, want %v", err, domain.ErrUploadNotFound)
+	}
+
+	signed, err := upload.SignUpload(ctx, "alice", "chat-1", domain.UploadedFile{Name: "hello.txt", ContentType: "text/plain", Size: 5}, time.Minute)
+	if err != nil {
+		t.Fatalf("SignUpload() error = %v", err)
+	}
+	_, err = upload.ReceiveUpload(ctx, signed.Path, domain.UploadedFile{File: strings.NewReader("hello, world")})
+	if !errors.Is(err, domain.ErrUploadSizeMismatch) {
+		t.Fatalf("ReceiveUpload() of oversized content error = %v, want %v", err, domain.ErrUploadSizeMismatch)
+	}
+	if _, err := repo.StatFile(ctx, signed.Path); !errors.Is(err, domain.ErrFileNotFound) {
+		t.Errorf("StatFile() error = %v, want the oversized content deleted", err)
+	}
+	if record, _ := metadata.GetFileRecord(ctx, signed.FileID); record.Status != domain.FileStatusFailed {
+		t.Errorf("record status = %q, want %q", record.Status, domain.FileStatusFailed)
+	}
+
+	signed, err = upload.SignUpload(ctx, "alice", "chat-1", domain.UploadedFile{Name: "hello.txt", ContentType: "text/plain", Size: 5}, time.Minute)
+	if err != nil {
+		t.Fatalf("SignUpload() error = %v", err)
+	}
+	result, err := upload.ReceiveUpload(ctx, signed.Path, domain.UploadedFile{File: strings.NewReader("hello")})
+	if err != nil {
+		t.Fatalf("ReceiveUpload() error = %v", err)
+	}
+	// SHA-256 of "hello".
+	const checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	record, err := metadata.GetFileRecord(ctx, signed.FileID)
+	if err != nil || record.Status != domain.FileStatusStored || record.Checksum != checksum || result.FileID != signed.FileID {
+		t.Errorf("GetFileRecord() = %+v, %v, want stored record with checksum %s", record, err, checksum)
+	}
+	if _, err := upload.FinalizeUpload(ctx, signed.FileID); !errors.Is(err, domain.ErrUploadNotFound) {
+		t.Errorf("FinalizeUpload() of stored upload error = %v, want %v", err, domain.ErrUploadNotFound)
+	}
+	if _, err := upload.FinalizeUpload(ctx, "9f0c5a6e-3b7d-4c1e-8a2f-5d6b7c8e9f00"); !errors.Is(err, domain.ErrUploadNotFound) {
+		t.Errorf("FinalizeUpload() of unknown upload error = %v, want %v", err, domain.ErrUploadNotFound)
+	}
+	if n := upload.(*signedUploadImpl).locks.len(); n != 0 {
+		t.Errorf("locks held after every call returned = %d, want 0", n)
+	}
+}
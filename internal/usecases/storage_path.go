@@ -0,0 +1,96 @@
+// internal/usecases/storage_path.go
+package usecases
+
+import (
+	"chat-backend-general/internal/domain"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSegmentLength is the longest path segment accepted, the common filesystem limit.
+const maxSegmentLength = 255
+
+// quarantinePrefix is the top-level prefix infected files are moved under. It is
+// reserved, so no user can list or download quarantined files as their own.
+const quarantinePrefix = "quarantine"
+
+// storagePath joins path segments, rejecting empty segments and anything that could
+// step outside of the user's namespace.
+func storagePath(segments ...string) (string, error) {
+	if len(segments) > 0 && segments[0] == quarantinePrefix {
+		return "", domain.ErrInvalidPath
+	}
+	for _, s := range segments {
+		if !validSegment(s) {
+			return "", domain.ErrInvalidPath
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// objectKey builds the storage key of a new file as owner/chatID/<fileID>_<filename>.
+// Prefixing the ID keeps uploads of the same filename from overwriting each other; the
+// original filename is kept in the file's metadata record.
+func objectKey(owner, chatID, fileID, filename string) (string, error) {
+	name, err := cleanFilename(filename)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := fileID + "_"
+	if len(prefix)+len(name) > maxSegmentLength {
+		name = truncateFilename(name, maxSegmentLength-len(prefix))
+	}
+	return storagePath(owner, chatID, prefix+name)
+}
+
+// cleanFilename normalizes a client supplied filename by dropping control characters and
+// surrounding whitespace and replacing invalid UTF-8. Names that still are not a single
+// segment are rejected; overlong names are left for objectKey to truncate.
+func cleanFilename(name string) (string, error) {
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if !validName(name) {
+		return "", domain.ErrInvalidPath
+	}
+	return name, nil
+}
+
+// validSegment reports whether s can be used as a single path segment.
+func validSegment(s string) bool {
+	return len(s) <= maxSegmentLength && validName(s)
+}
+
+// validName reports whether s is a single path element, regardless of its length.
+func validName(s string) bool {
+	if s == "" || s == "." || s == ".." || !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateFilename shortens name to at most n bytes, keeping its extension and
+// cutting on a rune boundary.
+func truncateFilename(name string, n int) string {
+	ext := path.Ext(name)
+	if len(ext) >= n {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+	limit := n - len(ext)
+	for limit > 0 && !utf8.RuneStart(base[limit]) {
+		limit--
+	}
+	return base[:limit] + ext
+}
@@ -0,0 +1,63 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"chat-backend-general/internal/domain"
+)
+
+func TestObjectKey(t *testing.T) {
+	const id = "0b5e6c1a-5d8e-4c61-9f3a-2f4d1c7b9e10"
+	longName := strings.Repeat("a", 300) + ".pdf"
+
+	tests := []struct {
+		name     string
+		owner    string
+		chatID   string
+		filename string
+		expected string
+		err      error
+	}{
+		{name: "plain", owner: "alice", chatID: "chat-1", filename: "report.pdf", expected: "alice/chat-1/" + id + "_report.pdf"},
+		{name: "surrounding whitespace", owner: "alice", chatID: "chat-1", filename: "  report.pdf\n", expected: "alice/chat-1/" + id + "_report.pdf"},
+		{name: "control characters", owner: "alice", chatID: "chat-1", filename: "re\x00port.pdf", expected: "alice/chat-1/" + id + "_report.pdf"},
+		{name: "unicode", owner: "alice", chatID: "chat-1", filename: "отчёт.pdf", expected: "alice/chat-1/" + id + "_отчёт.pdf"},
+		{name: "long name keeps extension", owner: "alice", chatID: "chat-1", filename: longName,
+			expected: "alice/chat-1/" + id + "_" + strings.Repeat("a", maxSegmentLength-len(id)-1-len(".pdf")) + ".pdf"},
+		{name: "traversal in filename", owner: "alice", chatID: "chat-1", filename: "../../etc/passwd", err: domain.ErrInvalidPath},
+		{name: "windows path", owner: "alice", chatID: "chat-1", filename: `C:\docs\report.pdf`, err: domain.ErrInvalidPath},
+		{name: "dot dot filename", owner: "alice", chatID: "chat-1", filename: "..", err: domain.ErrInvalidPath},
+		{name: "blank filename", owner: "alice", chatID: "chat-1", filename: " \t", err: domain.ErrInvalidPath},
+		{name: "empty owner", owner: "", chatID: "chat-1", filename: "report.pdf", err: domain.ErrInvalidPath},
+		{name: "traversal in chat", owner: "alice", chatID: "..", filename: "report.pdf", err: domain.ErrInvalidPath},
+		{name: "separator in owner", owner: "alice/bob", chatID: "chat-1", filename: "report.pdf", err: domain.ErrInvalidPath},
+		{name: "invalid utf-8 replaced", owner: "alice", chatID: "chat-1", filename: "\xff.pdf", expected: "alice/chat-1/" + id + "_\uFFFD.pdf"},
+		{name: "reserved quarantine owner", owner: "quarantine", chatID: "chat-1", filename: "report.pdf", err: domain.ErrInvalidPath},
+		{name: "invalid utf-8 in chat", owner: "alice", chatID: "\xff", filename: "report.pdf", err: domain.ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := objectKey(tt.owner, tt.chatID, id, tt.filename)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("objectKey(%q, %q, %q) error = %v, want %v", tt.owner, tt.chatID, tt.filename, err, tt.err)
+			}
+			if got != tt.expected {
+				t.Errorf("objectKey(%q, %q, %q) = %q, want %q", tt.owner, tt.chatID, tt.filename, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestObjectKey_TruncatesOnRuneBoundary(t *testing.T) {
+	got, err := objectKey("alice", "chat-1", "id", strings.Repeat("é", 200)+".txt")
+	if err != nil {
+		t.Fatalf("objectKey() error = %v", err)
+	}
+	name := got[len("alice/chat-1/"):]
+	if len(name) > maxSegmentLength || !strings.HasSuffix(name, ".txt") || !validSegment(name) {
+		t.Errorf("objectKey() segment = %q (%d bytes), want a valid segment of at most %d bytes ending in .txt", name, len(name), maxSegmentLength)
+	}
+}